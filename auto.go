@@ -0,0 +1,119 @@
+package bencode
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeAuto reads a single value from r which is either bencode
+// or its JSON representation, and returns the same tree the readers do.
+//
+// The format is picked by the first byte after any whitespace,
+// which bencode never has:
+//   - i, l and d start bencode;
+//   - {, [, ", -, t, f and n start JSON;
+//   - a digit is ambiguous, it's bencode when the digits are followed by
+//     a colon (a string length) and a JSON number otherwise.
+//
+// JSON numbers have to be integers, and true, false and null are rejected
+// since bencode has nothing to represent them with.
+func DecodeAuto(r io.Reader) (interface{}, error) {
+	br := bufio.NewReader(r)
+
+	for {
+		next, err := br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if !isJSONSpace(next[0]) {
+			break
+		}
+		_, _ = br.ReadByte()
+	}
+
+	isJSON, err := sniffJSON(br)
+	if err != nil {
+		return nil, err
+	}
+	if !isJSON {
+		return ReadValue(br)
+	}
+
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return fromJSON(v)
+}
+
+func isJSONSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// sniffJSON tells whether the value at the head of r is JSON.
+func sniffJSON(r *bufio.Reader) (bool, error) {
+	next, err := r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+
+	switch b := next[0]; {
+	case b == 'i' || b == 'l' || b == 'd':
+		return false, nil
+	case b >= '0' && b <= '9':
+		for n := 2; n <= r.Size(); n++ {
+			next, err := r.Peek(n)
+			if err != nil {
+				// Nothing but digits until the end of input: a JSON number.
+				return true, nil
+			}
+			if b := next[n-1]; b < '0' || b > '9' {
+				return b != stringSeparator, nil
+			}
+		}
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+// fromJSON converts a value decoded by encoding/json with UseNumber
+// into the types the readers produce.
+func fromJSON(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("json number %s is not an integer", v)
+		}
+		return int(i), nil
+	case []interface{}:
+		for i, e := range v {
+			e, err := fromJSON(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = e
+		}
+		return v, nil
+	case map[string]interface{}:
+		for k, e := range v {
+			e, err := fromJSON(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = e
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("json value %v has no bencode equivalent", v)
+	}
+}
@@ -0,0 +1,94 @@
+package bencode
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeAuto(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedErr   string
+	}{
+		// Bencode
+		{
+			name:          "bencode: dict",
+			in:            "d1:ai1ee",
+			expectedValue: map[string]interface{}{"a": 1},
+		},
+		{
+			name:          "bencode: string starting with a digit",
+			in:            "12:cheeseburger",
+			expectedValue: "cheeseburger",
+		},
+
+		// JSON
+		{
+			name: "json: object",
+			in:   ` {"a": [1, "b", {}]}`,
+			expectedValue: map[string]interface{}{
+				"a": []interface{}{1, "b", map[string]interface{}{}},
+			},
+		},
+		{
+			name:          "json: bare number",
+			in:            "42",
+			expectedValue: 42,
+		},
+		{
+			name:          "json: number followed by whitespace",
+			in:            "42 ",
+			expectedValue: 42,
+		},
+		{
+			name:          "json: negative number",
+			in:            "-7",
+			expectedValue: -7,
+		},
+		{
+			name:          "json: string",
+			in:            `"spam"`,
+			expectedValue: "spam",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: empty input",
+			in:          "  ",
+			expectedErr: io.EOF.Error(),
+		},
+		{
+			name:        "invalid: json float",
+			in:          "[1.5]",
+			expectedErr: "json number 1.5 is not an integer",
+		},
+		{
+			name:        "invalid: json bool",
+			in:          `{"private": true}`,
+			expectedErr: "json value true has no bencode equivalent",
+		},
+		{
+			name:        "invalid: broken bencode",
+			in:          "li1e",
+			expectedErr: io.EOF.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := DecodeAuto(strings.NewReader(test.in))
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package bencode
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dhtMessage is a typical DHT get_peers response: a handful of short keys.
+const dhtMessage = "d1:rd2:id20:abcdefghij01234567895:nodes9:def4567895:token8:aoeusnthe1:t2:aa1:y1:re"
+
+// fileListDict builds an info dictionary with n entries in its files list.
+func fileListDict(n int) string {
+	var b strings.Builder
+	b.WriteString("d5:filesl")
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("file%d.bin", i)
+		fmt.Fprintf(&b, "d6:lengthi%de4:pathl%d:%see", i*1024, len(name), name)
+	}
+	b.WriteString("e4:name4:test12:piece lengthi262144ee")
+
+	return b.String()
+}
+
+func benchmarkReadDictionary(b *testing.B, in string) {
+	data := []byte(in)
+	r := bufio.NewReader(bytes.NewReader(data))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Reset(bytes.NewReader(data))
+		if _, err := ReadDictionary(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Dictionary keys are read by readKey, which converts short keys straight
+// out of the bufio buffer instead of going through ReadString.
+//
+// Before:
+// BenchmarkReadDictionaryDHT         1505 ns/op     888 B/op      28 allocs/op
+// BenchmarkReadDictionaryFileList  727422 ns/op  475658 B/op   13025 allocs/op
+//
+// After:
+// BenchmarkReadDictionaryDHT         1376 ns/op     864 B/op      17 allocs/op
+// BenchmarkReadDictionaryFileList  713312 ns/op  474824 B/op   10021 allocs/op
+func BenchmarkReadDictionaryDHT(b *testing.B) {
+	benchmarkReadDictionary(b, dhtMessage)
+}
+
+func BenchmarkReadDictionaryFileList(b *testing.B) {
+	benchmarkReadDictionary(b, fileListDict(1000))
+}
+
+// listOfLists builds a list of n lists of four ints each.
+func listOfLists(n int) string {
+	var b strings.Builder
+	b.WriteString("l")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "li%dei1ei2ei3ee", i)
+	}
+	b.WriteString("e")
+
+	return b.String()
+}
+
+func benchmarkDecodeLists(b *testing.B, pooled bool) {
+	data := []byte(listOfLists(1000))
+	r := bufio.NewReader(bytes.NewReader(data))
+
+	d := &Decoder{r: r}
+	if pooled {
+		var free [][]interface{}
+		d.NewList = func() []interface{} {
+			if len(free) == 0 {
+				return nil
+			}
+			l := free[len(free)-1]
+			free = free[:len(free)-1]
+			return l
+		}
+		d.FreeList = func(l []interface{}) {
+			free = append(free, l)
+		}
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Reset(bytes.NewReader(data))
+		v, err := d.Decode()
+		if err != nil {
+			b.Fatal(err)
+		}
+		d.Release(v)
+	}
+}
+
+// Lists reuse their backing arrays when the decoder is given
+// NewList and FreeList, so a warmed up pool leaves only the
+// allocations of the ints boxed into interfaces.
+//
+// BenchmarkDecodeLists         577910 ns/op  192024 B/op   8757 allocs/op
+// BenchmarkDecodeListsPooled   478149 ns/op   44912 B/op   5747 allocs/op
+func BenchmarkDecodeLists(b *testing.B) {
+	benchmarkDecodeLists(b, false)
+}
+
+func BenchmarkDecodeListsPooled(b *testing.B) {
+	benchmarkDecodeLists(b, true)
+}
+
+// The body of a string is read with io.ReadFull rather than a byte
+// at a time.
+//
+// Before:
+// BenchmarkReadStringPieces  1090622 ns/op  1112816 B/op  25 allocs/op
+//
+// After:
+// BenchmarkReadStringPieces    60609 ns/op   409648 B/op   3 allocs/op
+func BenchmarkReadStringPieces(b *testing.B) {
+	// The pieces of a 10GB torrent with 1MB pieces.
+	data := []byte(fmt.Sprintf("%d:%s", 10240*20, strings.Repeat("x", 10240*20)))
+	r := bufio.NewReader(bytes.NewReader(data))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Reset(bytes.NewReader(data))
+		if _, err := ReadString(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkDecodeBytes(b *testing.B, in string, decode func(data []byte) (interface{}, error)) {
+	data := []byte(in)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decode(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func decodeBufio(data []byte) (interface{}, error) {
+	return ReadValue(bufio.NewReader(bytes.NewReader(data)))
+}
+
+// DecodeBytes reads the slice in place, which saves the buffer
+// of a bufio.Reader, the copying into it and the copy of every int.
+//
+// BenchmarkDecodeBufioDHT           2296 ns/op    5056 B/op     19 allocs/op
+// BenchmarkDecodeBytesDHT           1304 ns/op     872 B/op     18 allocs/op
+// BenchmarkDecodeBufioFileList    858662 ns/op  479016 B/op  10023 allocs/op
+// BenchmarkDecodeBytesFileList    685744 ns/op  459648 B/op   9021 allocs/op
+func BenchmarkDecodeBufioDHT(b *testing.B) {
+	benchmarkDecodeBytes(b, dhtMessage, decodeBufio)
+}
+
+func BenchmarkDecodeBytesDHT(b *testing.B) {
+	benchmarkDecodeBytes(b, dhtMessage, DecodeBytes)
+}
+
+func BenchmarkDecodeBufioFileList(b *testing.B) {
+	benchmarkDecodeBytes(b, fileListDict(1000), decodeBufio)
+}
+
+func BenchmarkDecodeBytesFileList(b *testing.B) {
+	benchmarkDecodeBytes(b, fileListDict(1000), DecodeBytes)
+}
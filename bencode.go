@@ -2,10 +2,19 @@ package bencode
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"strconv"
 )
 
+// Errors of the Read functions wrap these, along with the underlying
+// cause when there is one, so that both errors.Is(err, ErrIntInvalid)
+// and errors.Is(err, io.EOF) hold for an int cut short.
 var (
 	// ErrDictInvalid ...
 	ErrDictInvalid error = errors.New("invalid dict")
@@ -15,6 +24,11 @@ var (
 	ErrIntInvalid error = errors.New("invalid int")
 	// ErrStringInvalid ...
 	ErrStringInvalid error = errors.New("invalid string")
+	// ErrValueInvalid ...
+	ErrValueInvalid error = errors.New("invalid value")
+	// ErrIntOverflow is wrapped along with ErrIntInvalid when an int
+	// is well formed but doesn't fit into the type it's read into.
+	ErrIntOverflow error = errors.New("int overflow")
 )
 
 const stringSeparator = ':'
@@ -28,28 +42,139 @@ const stringSeparator = ':'
 // 4:wiki
 // is a string "wiki".
 func ReadString(r *bufio.Reader) (string, error) {
-	l, err := r.ReadBytes(stringSeparator)
+	length, err := readLength(r)
 	if err != nil {
-		return "", ErrStringInvalid
+		return "", err
 	}
-	length, err := strconv.Atoi(string(l[:len(l)-1]))
+
+	b, err := readBody(r, length)
 	if err != nil {
-		return "", ErrStringInvalid
+		return "", err
 	}
-	if length < 0 {
-		return "", ErrStringInvalid
+
+	return string(b), nil
+}
+
+// ReadBytes reads a string like ReadString does, but returns
+// its raw bytes, the way binary strings like the pieces of
+// a torrent are best kept.
+func ReadBytes(r *bufio.Reader) ([]byte, error) {
+	length, err := readLength(r)
+	if err != nil {
+		return nil, err
 	}
 
-	bs := []byte{}
-	for i := 0; i < length; i++ {
-		b, err := r.ReadByte()
-		if err != nil {
-			return "", ErrStringInvalid
+	return readBody(r, length)
+}
+
+// maxBodyAlloc is how much of a string body is allocated up front.
+// A longer body grows as its bytes arrive, so a length prefix
+// that lies can't make readBody allocate more than the input holds.
+const maxBodyAlloc = 1 << 20
+
+// readBody reads the length bytes following the prefix.
+func readBody(r source, length int) ([]byte, error) {
+	n := length
+	if n > maxBodyAlloc {
+		n = maxBodyAlloc
+	}
+
+	bs := make([]byte, n)
+	if _, err := io.ReadFull(r, bs); err != nil {
+		return nil, bodyError(err)
+	}
+	for len(bs) < length {
+		n = length - len(bs)
+		if n > len(bs) {
+			n = len(bs)
+		}
+		bs = append(bs, make([]byte, n)...)
+		if _, err := io.ReadFull(r, bs[len(bs)-n:]); err != nil {
+			return nil, bodyError(err)
+		}
+	}
+
+	return bs, nil
+}
+
+// bodyError is the error of a string body cut short.
+func bodyError(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return fmt.Errorf("%w: %w", ErrStringInvalid, err)
+}
+
+// readKey reads a dictionary key. It accepts exactly what ReadString does,
+// but a key that fits into the reader's buffer is converted straight from it,
+// so it costs a single allocation for the resulting string.
+func readKey(r source) (string, error) {
+	length, err := readLength(r)
+	if err != nil {
+		return "", err
+	}
+
+	return readKeyBody(r, length)
+}
+
+// readKeyBody reads the length bytes of a key following the prefix.
+func readKeyBody(r source, length int) (string, error) {
+	if length > r.Size() {
+		b, err := readBody(r, length)
+		return string(b), err
+	}
+
+	b, err := r.Peek(length)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrStringInvalid, err)
+	}
+	k := string(b)
+	_, _ = r.Discard(length)
+
+	return k, nil
+}
+
+// readLength reads the <length of string>: prefix of a string.
+//
+// On a slice, where all of the input is there already, a length longer
+// than what's left is refused right away instead of reading up to the end.
+func readLength(r source) (int, error) {
+	l, err := r.ReadSlice(stringSeparator)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrStringInvalid, err)
+	}
+
+	length, err := parseLength(l[:len(l)-1])
+	if err != nil {
+		return 0, err
+	}
+	if c, ok := r.(*byteCursor); ok && length > c.Buffered() {
+		return 0, fmt.Errorf("%w: length %d exceeds the %d bytes left: %w",
+			ErrStringInvalid, length, c.Buffered(), io.ErrUnexpectedEOF)
+	}
+
+	return length, nil
+}
+
+// parseLength parses the <length of string> part of a prefix.
+func parseLength(l []byte) (int, error) {
+	if len(l) == 0 {
+		return 0, fmt.Errorf("%w: no length", ErrStringInvalid)
+	}
+
+	length := 0
+	for _, b := range l {
+		if b < '0' || b > '9' {
+			return 0, fmt.Errorf("%w: bad length %q", ErrStringInvalid, l)
+		}
+		if length > (math.MaxInt-9)/10 {
+			return 0, fmt.Errorf("%w: length %s is out of range", ErrStringInvalid, l)
 		}
-		bs = append(bs, b)
+		length = length*10 + int(b-'0')
 	}
 
-	return string(bs), nil
+	return length, nil
 }
 
 // ReadInt reads a byte sequence and returns an integer.
@@ -60,22 +185,136 @@ func ReadString(r *bufio.Reader) (string, error) {
 // Example:
 // i90e
 // is an int 90.
+//
+// An int that doesn't fit into an int, e.g. a multi-gigabyte length
+// on a 32-bit platform, is an ErrIntOverflow. Use ReadInt64 for those.
 func ReadInt(r *bufio.Reader) (int, error) {
-	if b, _ := r.ReadByte(); b != 'i' {
-		return 0, ErrIntInvalid
+	return readInt(r)
+}
+
+func readInt(r source) (int, error) {
+	b, err := readIntBody(r)
+	if err != nil {
+		return 0, err
 	}
-	b, err := r.ReadBytes('e')
+	i, err := parseInt(b, strconv.IntSize)
 	if err != nil {
-		return 0, ErrIntInvalid
+		return 0, err
 	}
-	i, err := strconv.Atoi(string(b[:len(b)-1]))
+
+	return int(i), nil
+}
+
+// ReadInt64 reads an integer like ReadInt does, but into an int64,
+// which holds any file size regardless of the platform.
+func ReadInt64(r *bufio.Reader) (int64, error) {
+	b, err := readIntBody(r)
 	if err != nil {
-		return 0, ErrIntInvalid
+		return 0, err
+	}
+
+	return parseInt(b, 64)
+}
+
+// ReadBigInt reads an integer of any size.
+//
+// Example:
+// i18446744073709551616e
+// is 2^64, which overflows ReadInt64.
+//
+// Like ReadIntStrict it only accepts the canonical form,
+// so leading zeros and negative zero are invalid.
+func ReadBigInt(r *bufio.Reader) (*big.Int, error) {
+	b, err := readIntBody(r)
+	if err != nil {
+		return nil, err
+	}
+	i, ok := new(big.Int).SetString(string(b), 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a number", ErrIntInvalid, b)
+	}
+	if i.String() != string(b) {
+		return nil, fmt.Errorf("%w: %s is not canonical", ErrIntInvalid, b)
+	}
+
+	return i, nil
+}
+
+// parseInt parses the <integer> part of an int into bitSize bits.
+func parseInt(b []byte, bitSize int) (int64, error) {
+	i, err := strconv.ParseInt(string(b), 10, bitSize)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("%w: %w: %w", ErrIntInvalid, ErrIntOverflow, err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrIntInvalid, err)
 	}
 
 	return i, nil
 }
 
+// ReadIntLenient reads an integer like ReadInt does, but tolerates
+// ASCII whitespace around the digits.
+//
+// Example:
+// i 5 e
+// is an int 5.
+//
+// This is NOT valid bencode and ReadInt rejects it. It only exists
+// for tools which ingest hand-edited or otherwise massaged data.
+func ReadIntLenient(r *bufio.Reader) (int, error) {
+	b, err := readIntBody(r)
+	if err != nil {
+		return 0, err
+	}
+	i, err := parseInt(bytes.Trim(b, " \t\n\v\f\r"), strconv.IntSize)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(i), nil
+}
+
+// ReadIntStrict reads an integer like ReadInt does, but only accepts
+// the canonical form the spec demands: no leading zeros, no plus sign
+// and no negative zero.
+//
+// Example:
+// i03e and i-0e
+// are both invalid, while ReadInt reads them as 3 and 0.
+func ReadIntStrict(r *bufio.Reader) (int, error) {
+	b, err := readIntBody(r)
+	if err != nil {
+		return 0, err
+	}
+	i, err := parseInt(b, strconv.IntSize)
+	if err != nil {
+		return 0, err
+	}
+	if strconv.FormatInt(i, 10) != string(b) {
+		return 0, fmt.Errorf("%w: %s is not canonical", ErrIntInvalid, b)
+	}
+
+	return int(i), nil
+}
+
+// readIntBody reads i<integer>e and returns the <integer> part.
+func readIntBody(r source) ([]byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIntInvalid, err)
+	}
+	if b != 'i' {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrIntInvalid, b)
+	}
+	body, err := r.ReadBytes('e')
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIntInvalid, err)
+	}
+
+	return body[:len(body)-1], nil
+}
+
 // ReadList reads a byte sequence and tries to interpret it
 // as a []interface{}.
 //
@@ -89,50 +328,39 @@ func ReadInt(r *bufio.Reader) (int, error) {
 // However elements of the list are not necessarily are strings
 // they can be any bencoding type, distionaries included.
 func ReadList(r *bufio.Reader) ([]interface{}, error) {
-	if b, _ := r.ReadByte(); b != 'l' {
+	return (&Decoder{r: r}).readList()
+}
+
+func (d *Decoder) readList() ([]interface{}, error) {
+	if err := d.enter(); err != nil {
+		return nil, d.syntaxError(err, d.offset())
+	}
+	defer d.leave()
+
+	if b, _ := d.r.ReadByte(); b != 'l' {
 		return nil, ErrListInvalid
 	}
 
-	l := []interface{}{}
+	l := d.newList()
 	for {
-		next, err := r.Peek(1)
+		next, err := d.r.Peek(1)
 		if err != nil {
-			return nil, err
+			d.Release(l)
+			return nil, d.syntaxError(err, d.offset())
 		}
 
-		switch next[0] {
-		case 'e':
-			_, _ = r.ReadByte()
+		if next[0] == 'e' {
+			_, _ = d.r.ReadByte()
 			return l, nil
-		case 'l':
-			list, err := ReadList(r)
-			if err != nil {
-				return nil, err
-			}
-
-			l = append(l, list)
-		case 'd':
-			dict, err := ReadDictionary(r)
-			if err != nil {
-				return nil, err
-			}
-
-			l = append(l, dict)
-		case 'i':
-			i, err := ReadInt(r)
-			if err != nil {
-				return nil, err
-			}
-
-			l = append(l, i)
-		default:
-			s, err := ReadString(r)
-			if err != nil {
-				return nil, err
-			}
+		}
 
-			l = append(l, s)
+		v, err := d.readValue()
+		if err != nil {
+			d.Release(l)
+			return nil, err
 		}
+
+		l = append(l, v)
 	}
 }
 
@@ -150,52 +378,160 @@ func ReadList(r *bufio.Reader) ([]interface{}, error) {
 //
 // Is the name ParseDictionary more suitable?
 func ReadDictionary(r *bufio.Reader) (map[string]interface{}, error) {
-	if b, _ := r.ReadByte(); b != 'd' {
+	return (&Decoder{r: r}).readDictionary()
+}
+
+func (d *Decoder) readDictionary() (map[string]interface{}, error) {
+	if err := d.enter(); err != nil {
+		return nil, d.syntaxError(err, d.offset())
+	}
+	defer d.leave()
+
+	if b, _ := d.r.ReadByte(); b != 'd' {
 		return nil, ErrDictInvalid
 	}
 
-	d := make(map[string]interface{})
+	dict := make(map[string]interface{})
 
-	for {
-		next, err := r.Peek(1)
+	base := len(d.path)
+	var prev string
+	// skipped holds the keys KeyFilter left out, for RejectDuplicateKeys.
+	var skipped map[string]bool
+	for n := 0; ; n++ {
+		next, err := d.r.Peek(1)
 		if err != nil {
-			return nil, err
+			return nil, d.syntaxError(err, d.offset())
 		}
 		if next[0] == 'e' {
-			_, _ = r.ReadByte()
+			_, _ = d.r.ReadByte()
 			break
 		}
 
-		k, err := ReadString(r)
+		start := d.offset()
+		k, err := d.readKey()
 		if err != nil {
-			return nil, err
+			return nil, d.syntaxError(err, start)
+		}
+		if n != 0 && k <= prev && d.RejectUnsortedKeys {
+			return nil, d.syntaxError(fmt.Errorf("%w: %q after %q", ErrKeysNotSorted, k, prev), start)
+		}
+		prev = k
+		if d.KeyRewrite != nil {
+			k = d.KeyRewrite(k)
+		}
+		if _, ok := dict[k]; (ok || skipped[k]) && d.RejectDuplicateKeys {
+			return nil, d.syntaxError(fmt.Errorf("%w: %q", ErrDuplicateKey, k), start)
+		}
+		if d.onEntry != nil {
+			d.path = append(d.path[:base], k)
 		}
 
-		next, err = r.Peek(1)
+		next, err = d.r.Peek(1)
 		if err != nil {
-			return nil, err
+			return nil, d.syntaxError(err, d.offset())
 		}
 
-		var v interface{}
-		if next[0] != 'e' {
-			switch next[0] {
-			case 'd':
-				v, err = ReadDictionary(r)
-			case 'i':
-				v, err = ReadInt(r)
-			case 'l':
-				v, err = ReadList(r)
-			default:
-				v, err = ReadString(r)
+		if d.KeyFilter != nil && !d.KeyFilter(k) {
+			if d.RejectDuplicateKeys {
+				if skipped == nil {
+					skipped = make(map[string]bool)
+				}
+				skipped[k] = true
 			}
-
-			if err != nil {
-				return nil, err
+			if next[0] != 'e' {
+				if _, err := d.skipValue(false); err != nil {
+					return nil, err
+				}
 			}
+			continue
+		}
+
+		var v interface{}
+		switch {
+		case next[0] == 'e':
+		case d.OnValueError != nil:
+			v, err = d.readSkippableEntry(k)
+		default:
+			v, err = d.readEntry(k, next[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		dict[k] = v
+		if d.onEntry != nil {
+			d.onEntry(d.path, v)
+		}
+	}
+	d.path = d.path[:base]
+
+	return dict, nil
+}
+
+// ReadValue reads a value of whatever type comes next
+// by looking at its first byte:
+// i starts an int, l a list, d a dictionary
+// and a digit the length of a string.
+//
+// The value has the same type the matching function returns:
+// int, string, []interface{} or map[string]interface{}.
+// Any other first byte is an ErrValueInvalid.
+//
+// Lists and dictionaries nested deeper than DefaultMaxDepth,
+// here and in ReadList and ReadDictionary, are an ErrMaxDepthExceeded.
+func ReadValue(r *bufio.Reader) (interface{}, error) {
+	return (&Decoder{r: r}).readValue()
+}
+
+// ReadValueContext reads a value like ReadValue does, but gives up
+// with ctx.Err() as soon as ctx is done. The context is checked before
+// every value, nested ones included, and between the chunks
+// a long string is read in.
+//
+// A read that blocks on r is not interrupted, to bound that
+// use a deadline on the underlying connection, see ReadMessage.
+func ReadValueContext(ctx context.Context, r *bufio.Reader) (interface{}, error) {
+	return (&Decoder{r: r, ctx: ctx}).readValue()
+}
+
+func (d *Decoder) readValue() (interface{}, error) {
+	if d.ctx != nil {
+		if err := d.ctx.Err(); err != nil {
+			return nil, d.syntaxError(err, d.offset())
 		}
+	}
 
-		d[k] = v
+	next, err := d.r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	start := d.offset()
+
+	var v interface{}
+	switch b := next[0]; {
+	case b == 'l':
+		v, err = d.readList()
+	case b == 'd':
+		v, err = d.readDictionary()
+	case b == 'i':
+		if v, err = readInt(d.r); err != nil {
+			err = d.syntaxError(err, start)
+		}
+	case isDigit(b):
+		if v, err = d.readStringValue(); err != nil {
+			err = d.syntaxError(err, start)
+		}
+	default:
+		err = d.syntaxError(fmt.Errorf("%w: unexpected %q", ErrValueInvalid, b), start)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return d, nil
+	return v, nil
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
 }
@@ -2,13 +2,22 @@ package bencode
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"io"
+	"math"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// onePastMaxInt is math.MaxInt+1 spelled out. MaxInt ends with 7
+// on both 32 and 64-bit platforms, so bumping the last digit is enough.
+var onePastMaxInt = strconv.Itoa(math.MaxInt)[:len(strconv.Itoa(math.MaxInt))-1] + "8"
+
 func TestReadInt(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -37,6 +46,16 @@ func TestReadInt(t *testing.T) {
 			in:          "i-1e",
 			expectedInt: -1,
 		},
+		{
+			name:        "the largest int is valid",
+			in:          "i" + strconv.Itoa(math.MaxInt) + "e",
+			expectedInt: math.MaxInt,
+		},
+		{
+			name:        "the smallest int is valid",
+			in:          "i" + strconv.Itoa(math.MinInt) + "e",
+			expectedInt: math.MinInt,
+		},
 		{
 			name:        "i000000000000000000000e is a valid 0",
 			in:          "i000000000000000000000e",
@@ -74,6 +93,28 @@ func TestReadInt(t *testing.T) {
 			// io.EOF
 			expectedErr: ErrIntInvalid,
 		},
+		{
+			name: "invalid: i 5 e is not a valid int",
+			in:   "i 5 e",
+			// strconv.ErrSyntax
+			expectedErr: ErrIntInvalid,
+		},
+		// Overflow
+		{
+			name:        "invalid: one past the largest int",
+			in:          "i" + onePastMaxInt + "e",
+			expectedErr: ErrIntOverflow,
+		},
+		{
+			name:        "invalid: one past the smallest int",
+			in:          "i-" + onePastMaxInt[:len(onePastMaxInt)-1] + "9e",
+			expectedErr: ErrIntOverflow,
+		},
+		{
+			name:        "invalid: many digits",
+			in:          "i" + strings.Repeat("9", 100) + "e",
+			expectedErr: ErrIntOverflow,
+		},
 	}
 
 	for _, test := range tests {
@@ -82,7 +123,265 @@ func TestReadInt(t *testing.T) {
 			i, err := ReadInt(r)
 
 			if test.expectedErr != nil {
-				assert.EqualError(t, err, test.expectedErr.Error())
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedInt, i)
+			}
+		})
+	}
+}
+
+func TestReadInt64(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedInt int64
+		expectedErr error
+	}{
+		// Positive cases
+		{
+			name:        "valid: i42e is a valid 42",
+			in:          "i42e",
+			expectedInt: 42,
+		},
+		{
+			name:        "valid: a 3GB length fits on any platform",
+			in:          "i3221225472e",
+			expectedInt: 3221225472,
+		},
+		{
+			name:        "valid: the largest int64",
+			in:          "i9223372036854775807e",
+			expectedInt: math.MaxInt64,
+		},
+		{
+			name:        "valid: the smallest int64",
+			in:          "i-9223372036854775808e",
+			expectedInt: math.MinInt64,
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: one past the largest int64",
+			in:          "i9223372036854775808e",
+			expectedErr: ErrIntOverflow,
+		},
+		{
+			name:        "invalid: one past the smallest int64",
+			in:          "i-9223372036854775809e",
+			expectedErr: ErrIntOverflow,
+		},
+		{
+			name:        "invalid: not a number",
+			in:          "iae",
+			expectedErr: ErrIntInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			i, err := ReadInt64(r)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedInt, i)
+			}
+		})
+	}
+}
+
+func TestReadBigInt(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedInt string
+		expectedErr error
+	}{
+		// Positive cases
+		{
+			name:        "valid: i0e is a valid 0",
+			in:          "i0e",
+			expectedInt: "0",
+		},
+		{
+			name:        "valid: 2^64 doesn't fit into an int64",
+			in:          "i18446744073709551616e",
+			expectedInt: "18446744073709551616",
+		},
+		{
+			name:        "valid: many negative digits",
+			in:          "i-" + strings.Repeat("9", 100) + "e",
+			expectedInt: "-" + strings.Repeat("9", 100),
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: empty",
+			in:          "ie",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: leading zero",
+			in:          "i03e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: negative zero",
+			in:          "i-0e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: not a number",
+			in:          "i1ae",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: cut short",
+			in:          "i123",
+			expectedErr: io.EOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			i, err := ReadBigInt(r)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedInt, i.String())
+			}
+		})
+	}
+}
+
+func TestReadIntLenient(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedInt int
+		expectedErr error
+	}{
+		// Positive cases
+		{
+			name:        "valid: i5e is still a valid 5",
+			in:          "i5e",
+			expectedInt: 5,
+		},
+		{
+			name:        "valid: i 5 e is a valid 5",
+			in:          "i 5 e",
+			expectedInt: 5,
+		},
+		{
+			name:        "valid: tabs and newlines are whitespace too",
+			in:          "i\t-12\r\ne",
+			expectedInt: -12,
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: whitespace inside the digits",
+			in:          "i1 2e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: nothing but whitespace",
+			in:          "i e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: whitespace before i",
+			in:          " i5e",
+			expectedErr: ErrIntInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			i, err := ReadIntLenient(r)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedInt, i)
+			}
+		})
+	}
+}
+
+func TestReadIntStrict(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedInt int
+		expectedErr error
+	}{
+		// Positive cases
+		{
+			name:        "valid: i0e is a valid 0",
+			in:          "i0e",
+			expectedInt: 0,
+		},
+		{
+			name:        "valid: i-42e is a valid -42",
+			in:          "i-42e",
+			expectedInt: -42,
+		},
+		{
+			name:        "valid: i10e is a valid 10",
+			in:          "i10e",
+			expectedInt: 10,
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: leading zero",
+			in:          "i03e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: zeros only",
+			in:          "i000000000000000000000e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: negative zero",
+			in:          "i-0e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: negative with a leading zero",
+			in:          "i-05e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: plus sign",
+			in:          "i+5e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: empty",
+			in:          "ie",
+			expectedErr: ErrIntInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			i, err := ReadIntStrict(r)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, test.expectedInt, i)
@@ -144,7 +443,7 @@ func TestReadString(t *testing.T) {
 			r := bufio.NewReader(strings.NewReader(test.in))
 			s, err := ReadString(r)
 			if test.expectedErr != nil {
-				assert.EqualError(t, err, test.expectedErr.Error())
+				assert.ErrorIs(t, err, test.expectedErr)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, test.expectedString, s)
@@ -153,6 +452,87 @@ func TestReadString(t *testing.T) {
 	}
 }
 
+func TestReadBytes(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedBytes []byte
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: 4:wiki is wiki",
+			in:            "4:wiki",
+			expectedBytes: []byte("wiki"),
+		},
+		{
+			name:          "valid: binary bytes are kept as is",
+			in:            "3:\x00\xff\x80",
+			expectedBytes: []byte{0x00, 0xff, 0x80},
+		},
+		{
+			name:          "valid: empty",
+			in:            "0:",
+			expectedBytes: []byte{},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: no length",
+			in:          ":aaaa",
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: shorter than its length",
+			in:          "3:a",
+			expectedErr: ErrStringInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			b, err := ReadBytes(r)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedBytes, b)
+			}
+		})
+	}
+}
+
+func TestReadStringLong(t *testing.T) {
+	// Longer than what's allocated up front.
+	body := strings.Repeat("x", 3*maxBodyAlloc+1)
+	s, err := ReadString(bufio.NewReader(strings.NewReader(fmt.Sprintf("%d:%s", len(body), body))))
+	assert.NoError(t, err)
+	assert.Equal(t, body, s)
+
+	_, err = ReadString(bufio.NewReader(strings.NewReader(fmt.Sprintf("%d:%s", len(body)+1, body))))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// A length far beyond the input is found out without allocating it.
+	_, err = ReadString(bufio.NewReader(strings.NewReader("999999999999:abc")))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestReadErrorsWrapCause(t *testing.T) {
+	_, err := ReadInt(bufio.NewReader(strings.NewReader("i12")))
+	assert.ErrorIs(t, err, ErrIntInvalid)
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, err = ReadInt(bufio.NewReader(strings.NewReader("i99999999999999999999e")))
+	assert.ErrorIs(t, err, ErrIntInvalid)
+	assert.ErrorIs(t, err, ErrIntOverflow)
+	assert.ErrorIs(t, err, strconv.ErrRange)
+
+	_, err = ReadString(bufio.NewReader(strings.NewReader("5:ab")))
+	assert.ErrorIs(t, err, ErrStringInvalid)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
 func TestReadList(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -245,7 +625,7 @@ func TestReadList(t *testing.T) {
 			l, err := ReadList(r)
 
 			if test.expectedErr != nil {
-				assert.EqualError(t, err, test.expectedErr.Error())
+				assert.ErrorIs(t, err, test.expectedErr)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, test.expectedList, l)
@@ -341,11 +721,26 @@ func TestReadDictionary(t *testing.T) {
 			// io.EOF
 			expectedErr: ErrIntInvalid,
 		},
+		{
+			name:        "invalid: ends where a key is expected",
+			in:          "d",
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "invalid: ends where the next key is expected",
+			in:          "d1:ai1e",
+			expectedErr: io.EOF,
+		},
 		{
 			name:        "invalid: ends after the key",
 			in:          "d1:a",
 			expectedErr: io.EOF,
 		},
+		{
+			name:        "invalid: key is shorter than its length",
+			in:          "d5:ab",
+			expectedErr: ErrStringInvalid,
+		},
 	}
 
 	for _, test := range tests {
@@ -354,7 +749,7 @@ func TestReadDictionary(t *testing.T) {
 			d, err := ReadDictionary(r)
 
 			if err != nil {
-				assert.EqualError(t, err, test.expectedErr.Error())
+				assert.ErrorIs(t, err, test.expectedErr)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, test.expectedMap, d)
@@ -362,3 +757,244 @@ func TestReadDictionary(t *testing.T) {
 		})
 	}
 }
+
+func TestReadDictionaryLongKey(t *testing.T) {
+	// The key doesn't fit into the smallest bufio buffer
+	// and has to be read the slow way.
+	key := strings.Repeat("k", 100)
+	in := "d100:" + key + "i1e1:ai2ee"
+
+	r := bufio.NewReaderSize(strings.NewReader(in), 16)
+	d, err := ReadDictionary(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{key: 1, "a": 2}, d)
+}
+
+func TestReadValue(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedErr   string
+	}{
+		// Positive cases
+		{
+			name:          "valid: int",
+			in:            "i-3e",
+			expectedValue: -3,
+		},
+		{
+			name:          "valid: string",
+			in:            "4:spam",
+			expectedValue: "spam",
+		},
+		{
+			name:          "valid: list",
+			in:            "l4:spami1ee",
+			expectedValue: []interface{}{"spam", 1},
+		},
+		{
+			name:          "valid: dict",
+			in:            "d1:ali1eee",
+			expectedValue: map[string]interface{}{"a": []interface{}{1}},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: empty input",
+			in:          "",
+			expectedErr: io.EOF.Error(),
+		},
+		{
+			name:        "invalid: unexpected first byte",
+			in:          "x",
+			expectedErr: "invalid value: unexpected 'x'",
+		},
+		{
+			name:        "invalid: unexpected byte inside a list",
+			in:          "li1e-e",
+			expectedErr: "invalid value: unexpected '-'",
+		},
+		{
+			name:        "invalid: broken int",
+			in:          "iae",
+			expectedErr: `invalid int: strconv.ParseInt: parsing "a": invalid syntax`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			v, err := ReadValue(r)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
+
+func TestReadValueAcrossShortReads(t *testing.T) {
+	tests := []struct {
+		name          string
+		segments      []string
+		expectedValue interface{}
+	}{
+		{
+			name:          "int split at every byte class",
+			segments:      []string{"i", "42", "e"},
+			expectedValue: 42,
+		},
+		{
+			name:          "string split inside the length and the body",
+			segments:      []string{"1", "2:cheese", "burger"},
+			expectedValue: "cheeseburger",
+		},
+		{
+			name:          "dict split inside a key",
+			segments:      []string{"d6:len", "gthi", "1ee"},
+			expectedValue: map[string]interface{}{"length": 1},
+		},
+		{
+			name:          "list split before the end",
+			segments:      []string{"li1e", "", "e"},
+			expectedValue: []interface{}{1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			readers := make([]io.Reader, 0, len(test.segments))
+			for _, s := range test.segments {
+				readers = append(readers, strings.NewReader(s))
+			}
+
+			// Once as a MultiReader, which returns short reads
+			// at segment boundaries, and once a byte at a time.
+			for _, r := range []io.Reader{
+				io.MultiReader(readers...),
+				iotest.OneByteReader(strings.NewReader(strings.Join(test.segments, ""))),
+			} {
+				v, err := ReadValue(bufio.NewReader(r))
+
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("l", depth) + strings.Repeat("e", depth)
+	}
+	deepest, tooDeep := nested(DefaultMaxDepth), nested(DefaultMaxDepth+1)
+
+	read := func(in string) error {
+		_, err := ReadValue(bufio.NewReader(strings.NewReader(in)))
+		return err
+	}
+	assert.NoError(t, read(deepest))
+	assert.ErrorIs(t, read(tooDeep), ErrMaxDepthExceeded)
+	assert.ErrorIs(t, read("d1:a"+tooDeep+"e"), ErrMaxDepthExceeded)
+	// The limit is hit before the rest of the input is looked at.
+	assert.ErrorIs(t, read(strings.Repeat("l", 1000000)), ErrMaxDepthExceeded)
+
+	var v interface{}
+	assert.NoError(t, Unmarshal([]byte(deepest), &v))
+	assert.ErrorIs(t, Unmarshal([]byte(tooDeep), &v), ErrMaxDepthExceeded)
+
+	_, err := NormalizeIntegers([]byte(tooDeep))
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+
+	_, err = NewLazyDict([]byte("d1:a" + nested(DefaultMaxDepth) + "e"))
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+
+	_, err = StreamEqual(strings.NewReader(tooDeep), strings.NewReader(tooDeep))
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+}
+
+// cancelAfter calls cancel once n bytes have been read from r.
+type cancelAfter struct {
+	r      io.Reader
+	n      int
+	cancel func()
+}
+
+func (c *cancelAfter) Read(p []byte) (int, error) {
+	if c.n <= 0 {
+		c.cancel()
+	}
+	if len(p) > c.n && c.n > 0 {
+		p = p[:c.n]
+	}
+	n, err := c.r.Read(p)
+	c.n -= n
+
+	return n, err
+}
+
+func TestReadValueContext(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		cancelAfter   int
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: not cancelled",
+			in:            "d1:ali1ei2eee",
+			cancelAfter:   100,
+			expectedValue: map[string]interface{}{"a": []interface{}{1, 2}},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: cancelled before reading",
+			in:          "i1e",
+			expectedErr: context.Canceled,
+		},
+		{
+			name:        "invalid: cancelled inside a list",
+			in:          "li1ei2ei3ee",
+			cancelAfter: 4,
+			expectedErr: context.Canceled,
+		},
+		{
+			name:        "invalid: cancelled inside a long string",
+			in:          "10000:" + strings.Repeat("a", 10000),
+			cancelAfter: 100,
+			expectedErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancelAfter == 0 {
+				cancel()
+			}
+
+			r := bufio.NewReader(&cancelAfter{
+				r:      strings.NewReader(test.in),
+				n:      test.cancelAfter,
+				cancel: cancel,
+			})
+			v, err := ReadValueContext(ctx, r)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package bencode
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// ErrChecksumMismatch is returned when the checksum trailer
+// doesn't match the value it follows.
+var ErrChecksumMismatch error = errors.New("checksum mismatch")
+
+const checksumSize = 4
+
+// MarshalWithChecksum returns the bencoding of v followed by
+// the CRC-32 (IEEE) of it as 4 big-endian bytes.
+//
+// The trailer is outside of the value, so the output is still
+// read by DecodeOne and the Read functions, which stop before it.
+func MarshalWithChecksum(v interface{}) ([]byte, error) {
+	b, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b)), nil
+}
+
+// DecodeWithChecksum verifies the trailer MarshalWithChecksum
+// appends and decodes the value before it.
+//
+// A trailer that doesn't match is an ErrChecksumMismatch.
+// The value has to take up everything before the trailer.
+func DecodeWithChecksum(data []byte) (interface{}, error) {
+	if len(data) < checksumSize {
+		return nil, fmt.Errorf("%w: no checksum", ErrChecksumMismatch)
+	}
+	body, sum := data[:len(data)-checksumSize], data[len(data)-checksumSize:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return nil, ErrChecksumMismatch
+	}
+
+	v, rest, err := DecodeOne(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: %d bytes after the value", ErrTrailingData, len(rest))
+	}
+
+	return v, nil
+}
@@ -0,0 +1,75 @@
+package bencode
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalWithChecksum(t *testing.T) {
+	b, err := MarshalWithChecksum(map[string]interface{}{"a": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("d1:ai1eex\xf1\x98\xe3"), b)
+
+	v, rest, err := DecodeOne(b)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, v)
+	assert.Len(t, rest, 4)
+}
+
+func TestDecodeWithChecksum(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            []byte
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: dict",
+			in:            []byte("d1:ai1eex\xf1\x98\xe3"),
+			expectedValue: map[string]interface{}{"a": 1},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: checksum doesn't match",
+			in:          []byte("d1:ai2eex\xf1\x98\xe3"),
+			expectedErr: ErrChecksumMismatch,
+		},
+		{
+			name:        "invalid: shorter than a checksum",
+			in:          []byte("i1e"),
+			expectedErr: ErrChecksumMismatch,
+		},
+		{
+			name:        "invalid: checksum over a truncated value",
+			in:          []byte("d1:a\xaf\xb5\xbfX"),
+			expectedErr: io.EOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := DecodeWithChecksum(test.in)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
+
+func TestMarshalWithChecksumRoundTrip(t *testing.T) {
+	in := []interface{}{"spam", 42, map[string]interface{}{"x": []interface{}{}}}
+	b, err := MarshalWithChecksum(in)
+	assert.NoError(t, err)
+
+	v, err := DecodeWithChecksum(b)
+	assert.NoError(t, err)
+	assert.Equal(t, in, v)
+}
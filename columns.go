@@ -0,0 +1,149 @@
+package bencode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+)
+
+// ErrColumnsInvalid is returned when the rows read by ReadColumns
+// are not uniform dictionaries of ints and strings.
+var ErrColumnsInvalid error = errors.New("invalid columns")
+
+// Columns holds uniform dictionaries column by column:
+// the i-th value of every column belongs to the i-th row.
+type Columns struct {
+	// Keys are the keys the rows were found under when they came
+	// in a dictionary, in the order they were read. Nil for a list.
+	Keys []string
+	// Ints holds the columns of int values by dictionary key.
+	Ints map[string][]int64
+	// Strings holds the columns of string values by dictionary key.
+	Strings map[string][]string
+}
+
+// ReadColumns reads a list of dictionaries, or a dictionary of them,
+// into parallel slices, one per key, without building a map per row.
+//
+// Example:
+// d20:<info hash 1>d8:completei5e10:incompletei1ee20:<info hash 2>d8:completei2e10:incompletei0eee
+// gives Keys {<info hash 1>, <info hash 2>}
+// and Ints {"complete": {5, 2}, "incomplete": {1, 0}}.
+//
+// The first row decides the columns: every other row must have
+// the same keys with values of the same types. Only ints and strings
+// are allowed as values, anything else is an ErrColumnsInvalid.
+func ReadColumns(r *bufio.Reader) (*Columns, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Columns{
+		Ints:    make(map[string][]int64),
+		Strings: make(map[string][]string),
+	}
+	switch b {
+	case 'l':
+	case 'd':
+		c.Keys = []string{}
+	default:
+		return nil, fmt.Errorf("%w: unexpected %q", ErrColumnsInvalid, b)
+	}
+
+	d := &Decoder{r: r}
+	for n := 0; ; n++ {
+		next, err := r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if next[0] == 'e' {
+			_, _ = r.ReadByte()
+			return c, nil
+		}
+
+		if c.Keys != nil {
+			k, err := readKey(r)
+			if err != nil {
+				return nil, err
+			}
+			c.Keys = append(c.Keys, k)
+		}
+
+		if err := c.readRow(d, n); err != nil {
+			return nil, fmt.Errorf("row %d: %w", n, err)
+		}
+	}
+}
+
+// readRow appends the n-th row to the columns.
+func (c *Columns) readRow(d *Decoder, n int) error {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b != 'd' {
+		return fmt.Errorf("%w: unexpected %q", ErrColumnsInvalid, b)
+	}
+
+	for {
+		next, err := d.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		if next[0] == 'e' {
+			_, _ = d.r.ReadByte()
+			break
+		}
+
+		k, err := readKey(d.r)
+		if err != nil {
+			return err
+		}
+
+		next, err = d.r.Peek(1)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case next[0] == 'i':
+			if n > 0 && len(c.Ints[k]) != n {
+				return fmt.Errorf("%w: key %q: unexpected int", ErrColumnsInvalid, k)
+			}
+			body, err := readIntBody(d.r)
+			if err != nil {
+				return err
+			}
+			v, err := parseInt(body, 64)
+			if err != nil {
+				return err
+			}
+			c.Ints[k] = append(c.Ints[k], v)
+		case isDigit(next[0]):
+			if n > 0 && len(c.Strings[k]) != n {
+				return fmt.Errorf("%w: key %q: unexpected string", ErrColumnsInvalid, k)
+			}
+			v, err := d.readString()
+			if err != nil {
+				return err
+			}
+			c.Strings[k] = append(c.Strings[k], v)
+		default:
+			return fmt.Errorf("%w: key %q: not an int or a string", ErrColumnsInvalid, k)
+		}
+	}
+
+	for k, col := range c.Ints {
+		if len(col) != n+1 {
+			return fmt.Errorf("%w: key %q: missing", ErrColumnsInvalid, k)
+		}
+	}
+	for k, col := range c.Strings {
+		if len(col) != n+1 {
+			return fmt.Errorf("%w: key %q: missing", ErrColumnsInvalid, k)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package bencode
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadColumns(t *testing.T) {
+	tests := []struct {
+		name            string
+		in              string
+		expectedColumns *Columns
+		expectedErr     error
+	}{
+		// Positive cases
+		{
+			name: "valid: list of dicts",
+			in:   "ld1:ai1e1:b1:xed1:ai2e1:b1:yee",
+			expectedColumns: &Columns{
+				Ints:    map[string][]int64{"a": {1, 2}},
+				Strings: map[string][]string{"b": {"x", "y"}},
+			},
+		},
+		{
+			name: "valid: dict of dicts",
+			in:   "d2:h1d8:completei5e10:incompletei1ee2:h2d8:completei2e10:incompletei0eee",
+			expectedColumns: &Columns{
+				Keys:    []string{"h1", "h2"},
+				Ints:    map[string][]int64{"complete": {5, 2}, "incomplete": {1, 0}},
+				Strings: map[string][]string{},
+			},
+		},
+		{
+			// Past what an int holds on a 32-bit platform.
+			name: "valid: big ints",
+			in:   "ld4:sizei5000000000eed4:sizei-5000000000eee",
+			expectedColumns: &Columns{
+				Ints:    map[string][]int64{"size": {5000000000, -5000000000}},
+				Strings: map[string][]string{},
+			},
+		},
+		{
+			name: "valid: empty list",
+			in:   "le",
+			expectedColumns: &Columns{
+				Ints:    map[string][]int64{},
+				Strings: map[string][]string{},
+			},
+		},
+		{
+			name: "valid: empty dict",
+			in:   "de",
+			expectedColumns: &Columns{
+				Keys:    []string{},
+				Ints:    map[string][]int64{},
+				Strings: map[string][]string{},
+			},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: not a list or a dict",
+			in:          "i1e",
+			expectedErr: ErrColumnsInvalid,
+		},
+		{
+			name:        "invalid: row is not a dict",
+			in:          "li1ee",
+			expectedErr: ErrColumnsInvalid,
+		},
+		{
+			name:        "invalid: nested value",
+			in:          "ld1:alee",
+			expectedErr: ErrColumnsInvalid,
+		},
+		{
+			name:        "invalid: type changes between rows",
+			in:          "ld1:ai1eed1:a1:xee",
+			expectedErr: ErrColumnsInvalid,
+		},
+		{
+			name:        "invalid: key missing from a row",
+			in:          "ld1:ai1e1:bi2eed1:ai3eee",
+			expectedErr: ErrColumnsInvalid,
+		},
+		{
+			name:        "invalid: key not in the first row",
+			in:          "ld1:ai1eed1:ai2e1:bi3eee",
+			expectedErr: ErrColumnsInvalid,
+		},
+		{
+			name:        "invalid: input ends inside a row",
+			in:          "ld1:ai1e",
+			expectedErr: io.EOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			c, err := ReadColumns(r)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedColumns, c)
+			}
+		})
+	}
+}
+
+func TestReadColumnsErrorNamesRow(t *testing.T) {
+	_, err := ReadColumns(bufio.NewReader(strings.NewReader("ld1:ai1eed1:a1:xee")))
+	assert.EqualError(t, err, `row 1: invalid columns: key "a": unexpected string`)
+}
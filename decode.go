@@ -0,0 +1,138 @@
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// source is what a Decoder reads from: a *bufio.Reader over a stream,
+// or a byteCursor over a slice that's already in memory.
+type source interface {
+	io.Reader
+	io.ByteReader
+	Peek(n int) ([]byte, error)
+	ReadSlice(delim byte) ([]byte, error)
+	ReadBytes(delim byte) ([]byte, error)
+	Discard(n int) (int, error)
+	Buffered() int
+	Size() int
+}
+
+// byteCursor reads a slice in place, the way a *bufio.Reader
+// with the whole input in its buffer would.
+//
+// Unlike with a *bufio.Reader, what ReadBytes returns is part of the slice.
+// The Read functions only parse it, so that's never noticed.
+type byteCursor struct {
+	data []byte
+	pos  int
+}
+
+func (c *byteCursor) Read(p []byte) (int, error) {
+	if c.pos == len(c.data) && len(p) != 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, c.data[c.pos:])
+	c.pos += n
+
+	return n, nil
+}
+
+func (c *byteCursor) ReadByte() (byte, error) {
+	if c.pos == len(c.data) {
+		return 0, io.EOF
+	}
+	c.pos++
+
+	return c.data[c.pos-1], nil
+}
+
+func (c *byteCursor) Peek(n int) ([]byte, error) {
+	if rest := c.data[c.pos:]; n > len(rest) {
+		return rest, io.EOF
+	}
+
+	return c.data[c.pos : c.pos+n], nil
+}
+
+func (c *byteCursor) ReadSlice(delim byte) ([]byte, error) {
+	rest := c.data[c.pos:]
+	i := bytes.IndexByte(rest, delim)
+	if i < 0 {
+		c.pos = len(c.data)
+		return rest, io.EOF
+	}
+	c.pos += i + 1
+
+	return rest[:i+1], nil
+}
+
+func (c *byteCursor) ReadBytes(delim byte) ([]byte, error) {
+	return c.ReadSlice(delim)
+}
+
+func (c *byteCursor) Discard(n int) (int, error) {
+	if rest := len(c.data) - c.pos; n > rest {
+		c.pos = len(c.data)
+		return rest, io.EOF
+	}
+	c.pos += n
+
+	return n, nil
+}
+
+func (c *byteCursor) Buffered() int {
+	return len(c.data) - c.pos
+}
+
+func (c *byteCursor) Size() int {
+	return len(c.data)
+}
+
+// DecodeBytes decodes data, which has to hold exactly one value,
+// the way a Decoder does. Anything after the value is an ErrTrailingData.
+//
+// data is read in place instead of through a bufio.Reader, which makes
+// DecodeBytes the fastest way to decode what's already in memory.
+// Nothing in the result refers to data.
+func DecodeBytes(data []byte) (interface{}, error) {
+	c := &byteCursor{data: data}
+	// All of data counts as read, what's left of it is buffered,
+	// which gives the right offsets for errors.
+	d := &Decoder{r: c, cr: &countingReader{n: len(data)}}
+
+	v, err := d.readValue()
+	if err == io.EOF {
+		return nil, d.syntaxError(io.ErrUnexpectedEOF, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.pos != len(data) {
+		return nil, d.syntaxError(fmt.Errorf("%w: %d bytes after the value", ErrTrailingData, len(data)-c.pos), d.offset())
+	}
+
+	return v, nil
+}
+
+// DecodeOne decodes the value at the head of data and returns it
+// along with whatever follows it.
+//
+// Nothing after the value is looked at, which makes DecodeOne the way
+// to deal with bencode followed by something else, like an application
+// specific trailer: decode the value, then handle rest.
+//
+// Example:
+// i1e<trailer>
+// gives 1 and <trailer>.
+func DecodeOne(data []byte) (v interface{}, rest []byte, err error) {
+	c := &byteCursor{data: data}
+
+	v, err = (&Decoder{r: c}).readValue()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return v, data[c.pos:], nil
+}
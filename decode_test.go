@@ -0,0 +1,192 @@
+package bencode
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeOne(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedRest  string
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: nothing after the value",
+			in:            "i1e",
+			expectedValue: 1,
+			expectedRest:  "",
+		},
+		{
+			name:          "valid: binary trailer",
+			in:            "i1e\x00\xff\x10e",
+			expectedValue: 1,
+			expectedRest:  "\x00\xff\x10e",
+		},
+		{
+			name:          "valid: another value after the value",
+			in:            "d1:ai1ee4:spam",
+			expectedValue: map[string]interface{}{"a": 1},
+			expectedRest:  "4:spam",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: empty input",
+			in:          "",
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "invalid: broken value",
+			in:          "ixe",
+			expectedErr: ErrIntInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, rest, err := DecodeOne([]byte(test.in))
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+				assert.Equal(t, test.expectedRest, string(rest))
+			}
+		})
+	}
+}
+
+func TestDecodeOneLongTrailer(t *testing.T) {
+	// The trailer is longer than the bufio buffer.
+	trailer := make([]byte, 10000)
+	for i := range trailer {
+		trailer[i] = byte(i)
+	}
+
+	v, rest, err := DecodeOne(append([]byte("4:spam"), trailer...))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "spam", v)
+	assert.Equal(t, trailer, rest)
+}
+
+func TestDecodeBytes(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: int",
+			in:            "i-42e",
+			expectedValue: -42,
+		},
+		{
+			name: "valid: nested",
+			in:   "d1:ali1e1:be1:bd1:c0:ee",
+			expectedValue: map[string]interface{}{
+				"a": []interface{}{1, "b"},
+				"b": map[string]interface{}{"c": ""},
+			},
+		},
+		{
+			name:          "valid: key and string longer than a bufio buffer",
+			in:            "d10000:" + long + "10000:" + long + "e",
+			expectedValue: map[string]interface{}{long: long},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: empty input",
+			in:          "",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: trailing data",
+			in:          "i1ei2e",
+			expectedErr: ErrTrailingData,
+		},
+		{
+			name:        "invalid: list cut short",
+			in:          "li1e",
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "invalid: string cut short",
+			in:          "5:ab",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: broken int",
+			in:          "ixe",
+			expectedErr: ErrIntInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := DecodeBytes([]byte(test.in))
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
+
+func TestDecodeBytesLengthExceedsInput(t *testing.T) {
+	_, err := DecodeBytes([]byte("5:ab"))
+
+	assert.EqualError(t, err, "offset 0: invalid string: length 5 exceeds the 2 bytes left: unexpected EOF")
+	assert.ErrorIs(t, err, ErrStringInvalid)
+	assert.True(t, IsTruncated(err))
+}
+
+func TestDecodeBytesSyntaxError(t *testing.T) {
+	_, err := DecodeBytes([]byte("d1:ai1e1:bixee"))
+
+	var syntaxErr *SyntaxError
+	assert.True(t, errors.As(err, &syntaxErr))
+	assert.Equal(t, int64(10), syntaxErr.Offset)
+	assert.ErrorIs(t, err, ErrIntInvalid)
+}
+
+func TestDecodeBytesDoesNotKeepData(t *testing.T) {
+	data := []byte("d6:pieces2:ab4:name3:fooe")
+	v, err := DecodeBytes(data)
+	assert.NoError(t, err)
+
+	for i := range data {
+		data[i] = 'x'
+	}
+	assert.Equal(t, map[string]interface{}{"pieces": "ab", "name": "foo"}, v)
+}
+
+func TestDecodeBytesMatchesReadValue(t *testing.T) {
+	data := []byte(fileListDict(100))
+
+	expected, err := ReadValue(bufio.NewReader(bytes.NewReader(data)))
+	assert.NoError(t, err)
+
+	v, err := DecodeBytes(data)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, v)
+}
@@ -0,0 +1,484 @@
+package bencode
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxDepth is how deeply lists and dictionaries may nest
+// unless a Decoder is told otherwise.
+const DefaultMaxDepth = 100
+
+// ErrMaxDepthExceeded is returned when lists and dictionaries
+// nest deeper than allowed, before the one too many is read or written.
+var ErrMaxDepthExceeded error = errors.New("max depth exceeded")
+
+// ErrDuplicateKey is returned when a key appears twice in a dictionary
+// and the Decoder is set to reject that.
+var ErrDuplicateKey error = errors.New("duplicate key")
+
+// ErrKeysNotSorted is returned when the keys of a dictionary are not
+// in strictly increasing order and the Decoder is set to reject that.
+var ErrKeysNotSorted error = errors.New("keys not sorted")
+
+// ErrTrailingData is returned when there is more input
+// after a value which was supposed to be all of it.
+var ErrTrailingData error = errors.New("trailing data")
+
+// ErrStringTooLong is returned when a string is longer
+// than a Decoder allows.
+var ErrStringTooLong error = errors.New("string too long")
+
+// binaryKeys are the dictionary keys known to hold binary strings:
+// the v1 and v2 piece hashes, and the file hashes of BEP 47.
+var binaryKeys = map[string]bool{
+	"pieces":      true,
+	"pieces root": true,
+	"sha1":        true,
+	"ed2k":        true,
+	"md5sum":      true,
+}
+
+// binaryTrees are the dictionary keys whose values hold nothing but
+// binary strings, however deeply nested, like the piece layers of
+// a v2 torrent, a dictionary of hashes to hashes.
+var binaryTrees = map[string]bool{
+	"piece layers": true,
+}
+
+// SkippedValue takes the place of a dictionary value which failed
+// to decode and which the OnValueError of a Decoder chose to skip.
+type SkippedValue struct {
+	// Err is why the value failed to decode.
+	Err error
+}
+
+// SyntaxError is an error a Decoder ran into along with
+// the offset in the input where it happened.
+//
+// For an int or a string the offset is where the value starts,
+// for a list or a dictionary which ended too soon it's the offset
+// at which more input was expected.
+type SyntaxError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder reads bencoded values from an input stream.
+type Decoder struct {
+	r source
+	// cr counts the bytes read from the input, it's nil when the decoder
+	// serves one of the Read functions, which don't report offsets.
+	cr *countingReader
+
+	// StringDecoder, if not nil, converts the raw bytes of every string
+	// value to a Go string, e.g. from a legacy charset hinted at by
+	// the encoding key of a torrent. Dictionary keys and the known binary
+	// fields, pieces, pieces root, sha1, ed2k, md5sum and everything under
+	// piece layers, are not passed to it. By default the bytes are used as is.
+	StringDecoder func(raw []byte) (string, error)
+	// Bytes makes string values come out as []byte instead of string,
+	// without going through StringDecoder. Dictionary keys stay strings.
+	Bytes bool
+
+	// NewList, if not nil, provides the backing arrays of lists, e.g.
+	// from a sync.Pool, so they are reused across decodes. The slice it
+	// returns is truncated and appended to, so it may hold anything.
+	NewList func() []interface{}
+	// FreeList, if not nil, takes back the lists handed out by NewList.
+	// Release calls it, and so does a list that fails to decode.
+	FreeList func(l []interface{})
+
+	// MaxDepth limits how deeply lists and dictionaries may nest,
+	// which keeps hostile input like a million l bytes from eating
+	// the stack. Zero means DefaultMaxDepth.
+	MaxDepth int
+	depth    int
+	// binary counts the binaryTrees the decoder is in.
+	binary int
+
+	// MaxStringLength, if not zero, limits the length of strings,
+	// keys included. A longer one is refused as soon as its length
+	// is read, before anything is allocated for it.
+	MaxStringLength int
+
+	// RejectDuplicateKeys makes a key that appears twice in a dictionary
+	// an ErrDuplicateKey. By default the last value wins, like in
+	// d1:ai1e1:ai2ee which gives {"a": 2}.
+	RejectDuplicateKeys bool
+	// RejectUnsortedKeys makes a key that's not greater than the one
+	// before it, compared as raw bytes, an ErrKeysNotSorted, as the spec
+	// demands, e.g. to make sure an info dict is in canonical form.
+	RejectUnsortedKeys bool
+
+	// KeyRewrite, if not nil, turns every dictionary key into the one
+	// the value is stored under, e.g. to give an old key its new name:
+	//
+	//	d.KeyRewrite = func(key string) string {
+	//		if key == "announce_list" {
+	//			return "announce-list"
+	//		}
+	//		return key
+	//	}
+	//
+	// RejectUnsortedKeys checks the keys as they are in the input,
+	// everything else, RejectDuplicateKeys and KeyFilter included,
+	// sees the rewritten ones. Two keys rewritten to the same one
+	// are duplicates.
+	KeyRewrite func(key string) string
+
+	// KeyFilter, if not nil, is called with every dictionary key, those
+	// of nested dictionaries included. When it returns false, the value
+	// of the key is skipped over without being decoded or kept, and
+	// the key is left out of its dictionary. That takes next to no
+	// memory, e.g. for the pieces of a torrent when all that's needed
+	// is the info, its name and its length:
+	//
+	//	d.KeyFilter = func(key string) bool {
+	//		return key == "info" || key == "name" || key == "length"
+	//	}
+	//
+	// Duplicate and unsorted keys are found out before it's called.
+	KeyFilter func(key string) (decode bool)
+
+	// OnValueError, if not nil, is called with the key and the error
+	// of every dictionary value which fails to decode, e.g. a string
+	// StringDecoder refuses or an int too big for an int. If it returns
+	// true, a SkippedValue takes the place of the value and decoding
+	// goes on, which gets what's left out of a damaged torrent.
+	// Otherwise decoding stops with the error, as it does by default.
+	//
+	// Only a value which is well-formed bencode can be skipped, there's
+	// no telling where a broken one ends, so a syntax error stops
+	// decoding either way. Every value is read in full before it's
+	// decoded, so it has to fit in memory twice.
+	OnValueError func(key string, err error) (skip bool)
+
+	// ctx, if not nil, aborts decoding once it's done.
+	ctx context.Context
+
+	// onEntry, if not nil, is called with every dictionary entry as soon
+	// as its value is read. path holds the keys of the dictionaries
+	// the entry is in, outermost first, and its own key last. Lists
+	// have no key, so path is shorter than depth when it's in one.
+	onEntry func(path []string, v interface{})
+	path    []string
+}
+
+// NewDecoder returns a new decoder that reads from r.
+//
+// The decoder buffers its input and may read past the values it decodes.
+func NewDecoder(r io.Reader) *Decoder {
+	cr := &countingReader{r: r}
+
+	return &Decoder{r: bufio.NewReader(cr), cr: cr}
+}
+
+// NewDecoderSize returns a new decoder that reads from r through
+// a buffer of size bytes instead of the default 4096, e.g. a small one
+// for DHT messages of a few hundred bytes, or a big one for torrents
+// with megabytes of pieces.
+//
+// The size is only how much is read from r at once. Strings and keys
+// longer than the buffer are read past it, they don't have to fit.
+// Only a length prefix does, so sizes below 16 are raised to 16,
+// as bufio.NewReaderSize does.
+func NewDecoderSize(r io.Reader, size int) *Decoder {
+	cr := &countingReader{r: r}
+
+	return &Decoder{r: bufio.NewReaderSize(cr, size), cr: cr}
+}
+
+// Decode reads the next value from the stream, whatever its type.
+//
+// The decoder stops right after the value, so a stream
+// of several values is read with a Decode call for each.
+// Once the stream is over Decode returns io.EOF,
+// any other error is a *SyntaxError.
+func (d *Decoder) Decode() (interface{}, error) {
+	return d.readValue()
+}
+
+// DecodeComplete reads a value like Decode does, and makes sure
+// it was the last thing in the stream, e.g. the one dictionary
+// of a .torrent file. Anything after it is an ErrTrailingData,
+// while an empty stream is io.EOF as with Decode.
+func (d *Decoder) DecodeComplete() (interface{}, error) {
+	v, err := d.readValue()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = d.r.Peek(1)
+	if err == io.EOF {
+		return v, nil
+	}
+	if err != nil {
+		return nil, d.syntaxError(err, d.offset())
+	}
+
+	return nil, d.syntaxError(ErrTrailingData, d.offset())
+}
+
+// DecodeContext is Decode which gives up with ctx.Err()
+// as soon as ctx is done, see ReadValueContext.
+func (d *Decoder) DecodeContext(ctx context.Context) (interface{}, error) {
+	d.ctx = ctx
+	defer func() { d.ctx = nil }()
+
+	return d.readValue()
+}
+
+// InputOffset returns the number of bytes of input
+// the decoder has consumed so far.
+func (d *Decoder) InputOffset() int64 {
+	return d.offset()
+}
+
+func (d *Decoder) offset() int64 {
+	if d.cr == nil {
+		return 0
+	}
+
+	return int64(d.cr.n - d.r.Buffered())
+}
+
+// syntaxError wraps err with the offset where it happened,
+// unless the decoder doesn't keep track of offsets.
+func (d *Decoder) syntaxError(err error, offset int64) error {
+	if d.cr == nil {
+		return err
+	}
+
+	return &SyntaxError{Offset: offset, Err: err}
+}
+
+// enter goes one list or dictionary deeper, unless that's too deep.
+func (d *Decoder) enter() error {
+	max := d.MaxDepth
+	if max == 0 {
+		max = DefaultMaxDepth
+	}
+	if d.depth >= max {
+		return ErrMaxDepthExceeded
+	}
+	d.depth++
+
+	return nil
+}
+
+func (d *Decoder) leave() {
+	d.depth--
+}
+
+// Release hands every list in v, nested ones included, to FreeList
+// once v is no longer needed. The lists are cleared first so they don't
+// keep their elements alive. Nothing in v may be used afterwards.
+func (d *Decoder) Release(v interface{}) {
+	if d.FreeList == nil {
+		return
+	}
+
+	switch v := v.(type) {
+	case []interface{}:
+		for i, e := range v {
+			d.Release(e)
+			v[i] = nil
+		}
+		d.FreeList(v[:0])
+	case map[string]interface{}:
+		for _, e := range v {
+			d.Release(e)
+		}
+	}
+}
+
+func (d *Decoder) newList() []interface{} {
+	if d.NewList == nil {
+		return []interface{}{}
+	}
+
+	return d.NewList()[:0]
+}
+
+// readStringValue reads a string value as a string or
+// as []byte, whichever the decoder is set up for.
+func (d *Decoder) readStringValue() (interface{}, error) {
+	if d.Bytes {
+		return d.readBytes()
+	}
+	if d.binary > 0 {
+		return d.readBinary()
+	}
+
+	return d.readString()
+}
+
+// readEntry reads the value of the dictionary key k,
+// which starts with next.
+func (d *Decoder) readEntry(k string, next byte) (interface{}, error) {
+	switch {
+	case binaryKeys[k] && isDigit(next):
+		start := d.offset()
+		v, err := d.readBinary()
+		if err != nil {
+			return nil, d.syntaxError(err, start)
+		}
+		return v, nil
+	case binaryTrees[k]:
+		d.binary++
+		defer func() { d.binary-- }()
+	}
+
+	return d.readValue()
+}
+
+// skipValue skips the next value, and returns its raw bytes
+// if record is set.
+func (d *Decoder) skipValue(record bool) ([]byte, error) {
+	start := d.offset()
+	s := &Scanner{r: d.r, record: record}
+	if _, _, err := s.Skip(); err != nil {
+		var se *SyntaxError
+		if errors.As(err, &se) {
+			return nil, d.syntaxError(se.Err, start+se.Offset)
+		}
+		return nil, d.syntaxError(err, d.offset())
+	}
+
+	return s.raw, nil
+}
+
+// readSkippableEntry reads the value of the dictionary key k
+// in full, then decodes it, so OnValueError can skip it.
+func (d *Decoder) readSkippableEntry(k string) (interface{}, error) {
+	start := d.offset()
+	raw, err := d.skipValue(true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decoded by a copy which reads the value from memory,
+	// with offsets still counted from the start of the input.
+	sub := *d
+	sub.r = &byteCursor{data: raw}
+	if d.cr != nil {
+		sub.cr = &countingReader{n: int(start) + len(raw)}
+	}
+
+	v, err := sub.readEntry(k, raw[0])
+	if err != nil {
+		if !d.OnValueError(k, err) {
+			return nil, err
+		}
+		return SkippedValue{Err: err}, nil
+	}
+
+	return v, nil
+}
+
+// readBinary reads a string value which holds binary data.
+func (d *Decoder) readBinary() (interface{}, error) {
+	b, err := d.readBytes()
+	if err != nil || d.Bytes {
+		return b, err
+	}
+
+	return string(b), nil
+}
+
+func (d *Decoder) readString() (string, error) {
+	if d.StringDecoder == nil {
+		// Read like a key, which skips the []byte a short string
+		// would otherwise be copied through.
+		return d.readKey()
+	}
+
+	b, err := d.readBytes()
+	if err != nil {
+		return "", err
+	}
+
+	s, err := d.StringDecoder(b)
+	if err != nil {
+		return "", fmt.Errorf("decode string: %w", err)
+	}
+
+	return s, nil
+}
+
+// readBytes reads a string like ReadBytes does,
+// but refuses one longer than MaxStringLength.
+func (d *Decoder) readBytes() ([]byte, error) {
+	length, err := readLength(d.r)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkLength(length); err != nil {
+		return nil, err
+	}
+
+	return d.readBody(length)
+}
+
+// readBody reads a string body like readBody does, checking
+// the decoder's context before every bufferful.
+func (d *Decoder) readBody(length int) ([]byte, error) {
+	if d.ctx == nil {
+		return readBody(d.r, length)
+	}
+
+	bs := []byte{}
+	for len(bs) < length {
+		if err := d.ctx.Err(); err != nil {
+			return nil, err
+		}
+		n := length - len(bs)
+		if n > d.r.Size() {
+			n = d.r.Size()
+		}
+		bs = append(bs, make([]byte, n)...)
+		if _, err := io.ReadFull(d.r, bs[len(bs)-n:]); err != nil {
+			return nil, bodyError(err)
+		}
+	}
+
+	return bs, nil
+}
+
+// readKey reads a dictionary key like readKey does,
+// but refuses one longer than MaxStringLength.
+func (d *Decoder) readKey() (string, error) {
+	length, err := readLength(d.r)
+	if err != nil {
+		return "", err
+	}
+	if err := d.checkLength(length); err != nil {
+		return "", err
+	}
+	if length > d.r.Size() {
+		b, err := d.readBody(length)
+		return string(b), err
+	}
+
+	return readKeyBody(d.r, length)
+}
+
+func (d *Decoder) checkLength(length int) error {
+	if d.MaxStringLength > 0 && length > d.MaxStringLength {
+		return fmt.Errorf("%w: %d bytes", ErrStringTooLong, length)
+	}
+
+	return nil
+}
@@ -0,0 +1,715 @@
+package bencode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder(t *testing.T) {
+	tests := []struct {
+		name           string
+		in             string
+		expectedValues []interface{}
+		expectedErr    error
+		expectedOffset int64
+	}{
+		// Positive cases
+		{
+			name:           "valid: single int",
+			in:             "i1e",
+			expectedValues: []interface{}{1},
+		},
+		{
+			name: "valid: stream of values",
+			in:   "i1e4:spamli2eed1:ai3ee",
+			expectedValues: []interface{}{
+				1,
+				"spam",
+				[]interface{}{2},
+				map[string]interface{}{"a": 3},
+			},
+		},
+
+		// Negative cases
+		{
+			name:           "invalid: broken second value",
+			in:             "i1eixe",
+			expectedValues: []interface{}{1},
+			expectedErr:    ErrIntInvalid,
+			expectedOffset: 3,
+		},
+		{
+			name:           "invalid: unterminated list",
+			in:             "li1e",
+			expectedValues: []interface{}{},
+			expectedErr:    io.EOF,
+			expectedOffset: 4,
+		},
+		{
+			name:           "invalid: broken string deep inside",
+			in:             "d1:ald1:b5:xeee",
+			expectedValues: []interface{}{},
+			expectedErr:    ErrStringInvalid,
+			expectedOffset: 9,
+		},
+		{
+			name:           "invalid: broken key",
+			in:             "d1:ai1ei2ei3ee",
+			expectedValues: []interface{}{},
+			expectedErr:    ErrStringInvalid,
+			expectedOffset: 7,
+		},
+		{
+			name:           "invalid: unexpected byte",
+			in:             "i1eli1exe",
+			expectedValues: []interface{}{1},
+			expectedErr:    ErrValueInvalid,
+			expectedOffset: 7,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(test.in))
+
+			for _, expected := range test.expectedValues {
+				v, err := d.Decode()
+				assert.NoError(t, err)
+				assert.Equal(t, expected, v)
+			}
+
+			_, err := d.Decode()
+			if test.expectedErr != nil {
+				var syntaxErr *SyntaxError
+				assert.ErrorAs(t, err, &syntaxErr)
+				assert.ErrorIs(t, err, test.expectedErr)
+				assert.Equal(t, test.expectedOffset, syntaxErr.Offset)
+			} else {
+				assert.Equal(t, io.EOF, err)
+			}
+		})
+	}
+}
+
+func TestNewDecoderSize(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	in := "d4:infod4:name100:" + long + "6:pieces100:" + long + "e" +
+		"100:" + long + "i1ee" + "li-123456789ee"
+
+	for _, size := range []int{0, 16, 17, 64, 1 << 16} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			d := NewDecoderSize(strings.NewReader(in), size)
+
+			v, err := d.Decode()
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{
+				"info": map[string]interface{}{"name": long, "pieces": long},
+				long:   1,
+			}, v)
+			assert.Equal(t, int64(strings.Index(in, "l")), d.InputOffset())
+
+			v, err = d.Decode()
+			assert.NoError(t, err)
+			assert.Equal(t, []interface{}{-123456789}, v)
+
+			_, err = d.Decode()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestDecoderInputOffset(t *testing.T) {
+	d := NewDecoder(strings.NewReader("i1e4:spamle"))
+	assert.Equal(t, int64(0), d.InputOffset())
+
+	for _, expected := range []int64{3, 9, 11} {
+		_, err := d.Decode()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, d.InputOffset())
+	}
+}
+
+func TestDecoderStringDecoder(t *testing.T) {
+	// A stand-in for a charset decoder: Latin-1 to UTF-8.
+	latin1 := func(raw []byte) (string, error) {
+		runes := make([]rune, len(raw))
+		for i, b := range raw {
+			runes[i] = rune(b)
+		}
+		return string(runes), nil
+	}
+
+	d := NewDecoder(strings.NewReader("d4:name4:caf\xe96:pieces2:\xe9\xe95:filesl1:\xe9ee"))
+	d.StringDecoder = latin1
+
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":   "café",
+		"pieces": "\xe9\xe9",
+		"files":  []interface{}{"é"},
+	}, v)
+}
+
+func TestDecoderStringDecoderHybridTorrent(t *testing.T) {
+	// A v1 and v2 hybrid torrent, with the binary fields of both
+	// and a BEP 47 sha1, none of which may go through StringDecoder.
+	in := "d4:infod5:filesld6:lengthi1e4:pathl4:caf\xe9e4:sha12:\xe9\xe9ee" +
+		"9:file treed4:caf\xe9d0:d6:lengthi1e11:pieces root2:\xe9\xe9eee" +
+		"4:name4:caf\xe96:pieces2:\xe9\xe9e" +
+		"12:piece layersd2:\xe9\xe92:\xe9\xe9ee"
+
+	d := NewDecoder(strings.NewReader(in))
+	// Latin-1 to UTF-8, as in TestDecoderStringDecoder.
+	d.StringDecoder = func(raw []byte) (string, error) {
+		runes := make([]rune, len(raw))
+		for i, b := range raw {
+			runes[i] = rune(b)
+		}
+		return string(runes), nil
+	}
+
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"info": map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{
+					"length": 1,
+					"path":   []interface{}{"café"},
+					"sha1":   "\xe9\xe9",
+				},
+			},
+			"file tree": map[string]interface{}{
+				"caf\xe9": map[string]interface{}{
+					"": map[string]interface{}{"length": 1, "pieces root": "\xe9\xe9"},
+				},
+			},
+			"name":   "café",
+			"pieces": "\xe9\xe9",
+		},
+		"piece layers": map[string]interface{}{
+			"\xe9\xe9": "\xe9\xe9",
+		},
+	}, v)
+}
+
+func TestDecoderStringDecoderError(t *testing.T) {
+	d := NewDecoder(strings.NewReader("l1:xe"))
+	d.StringDecoder = func(raw []byte) (string, error) {
+		return "", errors.New("bad charset")
+	}
+
+	_, err := d.Decode()
+	assert.EqualError(t, err, "offset 1: decode string: bad charset")
+}
+
+func TestDecoderOnValueError(t *testing.T) {
+	// A damaged torrent: a name that's not Latin-1 the way
+	// StringDecoder wants it, and a length too big for an int64.
+	in := "d4:infod6:lengthi99999999999999999999e4:name2:\xff\xff" +
+		"6:pieces2:\xff\xff5:validi1eee"
+
+	var skipped []string
+	d := NewDecoder(strings.NewReader(in))
+	d.StringDecoder = func(raw []byte) (string, error) {
+		if bytes.IndexByte(raw, 0xff) >= 0 {
+			return "", errors.New("bad charset")
+		}
+		return string(raw), nil
+	}
+	d.OnValueError = func(key string, err error) bool {
+		skipped = append(skipped, key)
+		return key != "info"
+	}
+
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	info := v.(map[string]interface{})["info"].(map[string]interface{})
+	assert.Equal(t, 1, info["valid"])
+	assert.Equal(t, "\xff\xff", info["pieces"])
+
+	length := info["length"].(SkippedValue)
+	assert.ErrorIs(t, length.Err, ErrIntOverflow)
+	name := info["name"].(SkippedValue)
+	var se *SyntaxError
+	assert.ErrorAs(t, name.Err, &se)
+	assert.Equal(t, int64(strings.Index(in, "2:")), se.Offset)
+	assert.Equal(t, []string{"length", "name"}, skipped)
+}
+
+func TestDecoderOnValueErrorAborts(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedErr error
+	}{
+		{
+			name:        "OnValueError returns false",
+			in:          "d1:ai99999999999999999999ee",
+			expectedErr: ErrIntOverflow,
+		},
+		{
+			// There's no telling where a broken value ends.
+			name:        "a syntax error",
+			in:          "d1:bi1x2e1:ci1ee",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "cut short",
+			in:          "d1:bli1e",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(test.in))
+			d.OnValueError = func(key string, err error) bool { return key != "a" }
+
+			_, err := d.Decode()
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestDecoderKeyFilter(t *testing.T) {
+	pieces := strings.Repeat("x", 1<<16)
+	in := "d8:announce3:url4:infod6:lengthi7e4:name1:a6:pieces" +
+		strconv.Itoa(len(pieces)) + ":" + pieces + "e3:nowi1ee"
+
+	var keys []string
+	d := NewDecoder(strings.NewReader(in + "i2e"))
+	d.KeyFilter = func(key string) bool {
+		keys = append(keys, key)
+		return key == "info" || key == "name" || key == "length"
+	}
+
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"info": map[string]interface{}{"length": 7, "name": "a"},
+	}, v)
+	assert.Equal(t, []string{"announce", "info", "length", "name", "pieces", "now"}, keys)
+	assert.Equal(t, int64(len(in)), d.InputOffset())
+
+	v, err = d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+}
+
+func TestDecoderKeyFilterErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedErr error
+	}{
+		{
+			name:        "a skipped value cut short",
+			in:          "d1:a10:abce",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "a skipped list cut short",
+			in:          "d1:ali1e",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "a broken skipped value",
+			in:          "d1:ai1x2ee",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "unsorted keys",
+			in:          "d1:bi1e1:ai2ee",
+			expectedErr: ErrKeysNotSorted,
+		},
+		{
+			name:        "duplicate keys",
+			in:          "d1:ai1e1:ai2ee",
+			expectedErr: ErrDuplicateKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(test.in))
+			d.KeyFilter = func(string) bool { return false }
+			d.RejectDuplicateKeys = true
+			d.RejectUnsortedKeys = test.expectedErr == ErrKeysNotSorted
+
+			_, err := d.Decode()
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestDecoderKeyRewrite(t *testing.T) {
+	rewrite := func(key string) string {
+		if key == "announce_list" {
+			return "announce-list"
+		}
+		return strings.ToLower(key)
+	}
+
+	// Positive cases
+	d := NewDecoder(strings.NewReader("d4:INFOd6:PIECES1:\xffe13:announce_listl1:aee"))
+	d.KeyRewrite = rewrite
+	d.RejectUnsortedKeys = true
+	d.StringDecoder = func(raw []byte) (string, error) {
+		return strings.ToUpper(string(raw)), nil
+	}
+
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"announce-list": []interface{}{"A"},
+		"info":          map[string]interface{}{"pieces": "\xff"},
+	}, v)
+
+	// Negative cases
+	tests := []struct {
+		name        string
+		in          string
+		expectedErr error
+	}{
+		{
+			name:        "two keys rewritten to one",
+			in:          "d1:Ai1e1:ai2ee",
+			expectedErr: ErrDuplicateKey,
+		},
+		{
+			name:        "unsorted before rewriting",
+			in:          "d13:announce_listle8:announcei1ee",
+			expectedErr: ErrKeysNotSorted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(test.in))
+			d.KeyRewrite = rewrite
+			d.RejectDuplicateKeys = true
+			d.RejectUnsortedKeys = true
+
+			_, err := d.Decode()
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestDecoderListPool(t *testing.T) {
+	var handedOut, freed int
+	d := NewDecoder(strings.NewReader("ld1:ali1eeeli2eeelx"))
+	d.NewList = func() []interface{} {
+		handedOut++
+		return make([]interface{}, 3, 8)
+	}
+	d.FreeList = func(l []interface{}) {
+		assert.Len(t, l, 0)
+		freed++
+	}
+
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"a": []interface{}{1}},
+		[]interface{}{2},
+	}, v)
+	assert.Equal(t, 3, handedOut)
+
+	d.Release(v)
+	assert.Equal(t, 3, freed)
+
+	// A list that fails to decode goes back right away.
+	_, err = d.Decode()
+	assert.ErrorIs(t, err, ErrValueInvalid)
+	assert.Equal(t, 4, handedOut)
+	assert.Equal(t, 4, freed)
+}
+
+func TestDecoderBytes(t *testing.T) {
+	d := NewDecoder(strings.NewReader("d4:name4:test6:pieces2:\x00\xff5:filesl1:aee"))
+	d.Bytes = true
+	d.StringDecoder = func(raw []byte) (string, error) {
+		return "", errors.New("not called")
+	}
+
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":   []byte("test"),
+		"pieces": []byte{0x00, 0xff},
+		"files":  []interface{}{[]byte("a")},
+	}, v)
+}
+
+func TestDecoderMaxDepth(t *testing.T) {
+	d := NewDecoder(strings.NewReader("lleelllee"))
+	d.MaxDepth = 2
+
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{}}, v)
+
+	_, err = d.Decode()
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+
+	var syntaxErr *SyntaxError
+	assert.True(t, errors.As(err, &syntaxErr))
+	assert.Equal(t, int64(6), syntaxErr.Offset)
+}
+
+func TestDecoderMaxStringLength(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: string at the limit",
+			in:            "4:spam",
+			expectedValue: "spam",
+		},
+		{
+			name:          "valid: dict with short keys and values",
+			in:            "d1:a4:spame",
+			expectedValue: map[string]interface{}{"a": "spam"},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: string over the limit",
+			in:          "5:spams",
+			expectedErr: ErrStringTooLong,
+		},
+		{
+			name:        "invalid: key over the limit",
+			in:          "d5:spamsi1ee",
+			expectedErr: ErrStringTooLong,
+		},
+		{
+			name:        "invalid: string over the limit deep in a list",
+			in:          "ll5:spamsee",
+			expectedErr: ErrStringTooLong,
+		},
+		{
+			name:        "invalid: huge length with no data behind it",
+			in:          "99999999999:",
+			expectedErr: ErrStringTooLong,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(test.in))
+			d.MaxStringLength = 4
+			v, err := d.Decode()
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
+
+func TestDecoderRejectDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: unique keys",
+			in:            "d1:ai1e1:bi2ee",
+			expectedValue: map[string]interface{}{"a": 1, "b": 2},
+		},
+		{
+			name: "valid: same key in different dicts",
+			in:   "d1:ad1:ai1eee",
+			expectedValue: map[string]interface{}{
+				"a": map[string]interface{}{"a": 1},
+			},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: key repeats",
+			in:          "d1:ai1e1:ai2ee",
+			expectedErr: ErrDuplicateKey,
+		},
+		{
+			name:        "invalid: key repeats in a nested dict",
+			in:          "ld1:ai1e1:bi2e1:ai3eee",
+			expectedErr: ErrDuplicateKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(test.in))
+			d.RejectDuplicateKeys = true
+			v, err := d.Decode()
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+
+	v, err := NewDecoder(strings.NewReader("d1:ai1e1:ai2ee")).Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 2}, v)
+}
+
+func TestDecoderRejectUnsortedKeys(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: sorted keys",
+			in:            "d1:ai1e2:aai2e1:bi3ee",
+			expectedValue: map[string]interface{}{"a": 1, "aa": 2, "b": 3},
+		},
+		{
+			name:          "valid: keys sort as bytes, not runes",
+			in:            "d1:Zi1e1:ai2e2:\xc3\xa9i3ee",
+			expectedValue: map[string]interface{}{"Z": 1, "a": 2, "\xc3\xa9": 3},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: keys out of order",
+			in:          "d1:bi1e1:ai2ee",
+			expectedErr: ErrKeysNotSorted,
+		},
+		{
+			name:        "invalid: prefix after the longer key",
+			in:          "d2:aai1e1:ai2ee",
+			expectedErr: ErrKeysNotSorted,
+		},
+		{
+			name:        "invalid: key repeats",
+			in:          "d1:ai1e1:ai2ee",
+			expectedErr: ErrKeysNotSorted,
+		},
+		{
+			name:        "invalid: keys out of order in a nested dict",
+			in:          "d1:ad1:bi1e1:ai2eee",
+			expectedErr: ErrKeysNotSorted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(test.in))
+			d.RejectUnsortedKeys = true
+			v, err := d.Decode()
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
+
+func TestDecoderDecodeContext(t *testing.T) {
+	d := NewDecoder(strings.NewReader("i1ei2e"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	v, err := d.DecodeContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	cancel()
+	_, err = d.DecodeContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The context only applies to the DecodeContext call.
+	v, err = d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+}
+
+func TestDecoderDecodeComplete(t *testing.T) {
+	tests := []struct {
+		name           string
+		in             string
+		expectedValue  interface{}
+		expectedErr    error
+		expectedOffset int64
+	}{
+		// Positive cases
+		{
+			name:          "valid: one dict",
+			in:            "d1:ai1ee",
+			expectedValue: map[string]interface{}{"a": 1},
+		},
+
+		// Negative cases
+		{
+			name:           "invalid: junk after the dict",
+			in:             "d1:ai1eexx",
+			expectedErr:    ErrTrailingData,
+			expectedOffset: 8,
+		},
+		{
+			name:           "invalid: another value after the dict",
+			in:             "dei1e",
+			expectedErr:    ErrTrailingData,
+			expectedOffset: 2,
+		},
+		{
+			name:        "invalid: empty input",
+			in:          "",
+			expectedErr: io.EOF,
+		},
+		{
+			name:           "invalid: dict cut short",
+			in:             "d1:a",
+			expectedErr:    io.EOF,
+			expectedOffset: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := NewDecoder(strings.NewReader(test.in)).DecodeComplete()
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				var syntaxErr *SyntaxError
+				if errors.As(err, &syntaxErr) {
+					assert.Equal(t, test.expectedOffset, syntaxErr.Offset)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
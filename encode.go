@@ -0,0 +1,404 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ErrTypeUnsupported is returned when a value has no bencode representation.
+var ErrTypeUnsupported error = errors.New("unsupported type")
+
+// ErrCycle is returned when a value contains itself
+// through a map or a pointer, so its encoding would never end.
+var ErrCycle error = errors.New("value contains itself")
+
+// Marshaler is implemented by types which encode themselves.
+//
+// MarshalBencode has to return exactly one valid bencoded value.
+// Its bytes are written as they are, without being checked, so
+// anything else corrupts the output around them.
+type Marshaler interface {
+	MarshalBencode() ([]byte, error)
+}
+
+// Marshal returns the bencoding of v.
+//
+// v may be anything the readers produce: an int, a string,
+// a []interface{} or a map[string]interface{}, nested in any way.
+//
+// Structs and pointers to them are encoded as dictionaries, with keys
+// taken from the field tags the same way Unmarshal does. The fields
+// of embedded structs are promoted, and when several fields share
+// a key only the one encoding/json would pick is written. A field tagged
+// bencode:"name,omitempty" is left out when it has its zero value,
+// a nil pointer field always is, which makes *string or *int the way
+// to an optional key. A non-nil one is written as what it points to.
+// Fields may be of any integer type, strings, []byte, slices, arrays,
+// maps with string keys, structs and pointers to any of these.
+// What a Marshaler returns is written as is, e.g. a RawMessage.
+//
+// Dictionary keys are written sorted as raw byte strings, the way
+// bytes.Compare orders them, so a key sorts right after its prefixes.
+//
+// A value which contains itself, e.g. a map m with m["a"] = m,
+// is an ErrCycle. Lists and dictionaries nested deeper than
+// DefaultMaxDepth are an ErrMaxDepthExceeded, as they would be
+// when decoded.
+//
+// Example:
+// map[string]interface{}{"spam": []interface{}{"a", 1}}
+// is encoded as d4:spaml1:ai1eee
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := (&encodeState{w: &buf}).writeValue(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// EncodeEach writes values to w one after another and returns
+// how many of them were written.
+//
+// Each value is encoded in full before anything is written,
+// so when one can't be encoded the output ends cleanly after
+// the previous one. The error tells the index of the failed value.
+func EncodeEach(w io.Writer, values []interface{}) (int, error) {
+	var buf bytes.Buffer
+	for i, v := range values {
+		buf.Reset()
+		if err := (&encodeState{w: &buf}).writeValue(v); err != nil {
+			return i, fmt.Errorf("value %d: %w", i, err)
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return i, fmt.Errorf("value %d: %w", i, err)
+		}
+	}
+
+	return len(values), nil
+}
+
+// WriteInt writes an integer in its bencoding form:
+// i<integer>e
+//
+// Example:
+// -17
+// is written as i-17e.
+func WriteInt(w io.Writer, n int) error {
+	_, err := io.WriteString(w, "i"+strconv.Itoa(n)+"e")
+	return err
+}
+
+// WriteString writes a string in its bencoding form:
+// <length of string>:<string>
+//
+// The length is in bytes, not runes.
+//
+// Example:
+// héllo
+// is written as 6:héllo.
+func WriteString(w io.Writer, s string) error {
+	_, err := io.WriteString(w, strconv.Itoa(len(s))+string(stringSeparator)+s)
+	return err
+}
+
+// WriteList writes a list in its bencoding form:
+// l[value 1][value2][...]e
+//
+// Elements may be of any type Marshal accepts, lists and
+// dictionaries included.
+//
+// Example:
+// []interface{}{"spam", 42}
+// is written as l4:spami42ee.
+func WriteList(w io.Writer, l []interface{}) error {
+	return (&encodeState{w: w}).writeList(l)
+}
+
+func (e *encodeState) writeList(l []interface{}) error {
+	if err := e.enter(); err != nil {
+		return err
+	}
+	defer e.leave()
+
+	if _, err := io.WriteString(e.w, "l"); err != nil {
+		return err
+	}
+	for i, v := range l {
+		if err := e.writeValue(v); err != nil {
+			return fmt.Errorf("list index %d: %w", i, err)
+		}
+	}
+	_, err := io.WriteString(e.w, "e")
+
+	return err
+}
+
+// WriteDictionary writes a dictionary in its bencoding form:
+// d[key1][value1][key2][value2][...]e
+//
+// Keys are written sorted as raw byte strings, as the spec demands,
+// so the same map always gives the same bytes.
+// Values may be of any type Marshal accepts.
+//
+// Example:
+// map[string]interface{}{"spam": "eggs", "cow": "moo"}
+// is written as d3:cow3:moo4:spam4:eggse.
+func WriteDictionary(w io.Writer, d map[string]interface{}) error {
+	return (&encodeState{w: w}).writeDictionary(d)
+}
+
+func (e *encodeState) writeDictionary(d map[string]interface{}) error {
+	if err := e.visit(reflect.ValueOf(d)); err != nil {
+		return err
+	}
+	defer e.unvisit(reflect.ValueOf(d))
+	if err := e.enter(); err != nil {
+		return err
+	}
+	defer e.leave()
+
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if _, err := io.WriteString(e.w, "d"); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := WriteString(e.w, k); err != nil {
+			return err
+		}
+		if err := e.writeValue(d[k]); err != nil {
+			return fmt.Errorf("dict key %q: %w", k, err)
+		}
+	}
+	_, err := io.WriteString(e.w, "e")
+
+	return err
+}
+
+// encodeState is what the encoding of one value keeps track of:
+// how deeply lists and dictionaries nest, and which maps and pointers
+// it's inside of, so a value which contains itself is an error
+// instead of a stack overflow.
+type encodeState struct {
+	w     io.Writer
+	depth int
+	seen  map[identity]bool
+}
+
+// identity tells maps and pointers apart. A pointer to a struct and
+// one to its first field have the same address, but not the same type.
+type identity struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// enter goes one list or dictionary deeper, unless that's too deep.
+func (e *encodeState) enter() error {
+	if e.depth >= DefaultMaxDepth {
+		return ErrMaxDepthExceeded
+	}
+	e.depth++
+
+	return nil
+}
+
+func (e *encodeState) leave() {
+	e.depth--
+}
+
+// visit marks the map or pointer rv as being encoded,
+// unless it already is, which makes it a cycle.
+func (e *encodeState) visit(rv reflect.Value) error {
+	if rv.IsNil() {
+		return nil
+	}
+
+	id := identity{ptr: rv.Pointer(), typ: rv.Type()}
+	if e.seen[id] {
+		return fmt.Errorf("%w: %s", ErrCycle, rv.Type())
+	}
+	if e.seen == nil {
+		e.seen = make(map[identity]bool)
+	}
+	e.seen[id] = true
+
+	return nil
+}
+
+func (e *encodeState) unvisit(rv reflect.Value) {
+	if !rv.IsNil() {
+		delete(e.seen, identity{ptr: rv.Pointer(), typ: rv.Type()})
+	}
+}
+
+// writeValue writes v picking the encoding by its type.
+func (e *encodeState) writeValue(v interface{}) error {
+	var err error
+	switch v := v.(type) {
+	case int:
+		err = WriteInt(e.w, v)
+	case string:
+		err = WriteString(e.w, v)
+	case []interface{}:
+		err = e.writeList(v)
+	case map[string]interface{}:
+		err = e.writeDictionary(v)
+	case Marshaler:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return e.writeReflect(rv)
+		}
+		b, err := v.MarshalBencode()
+		if err != nil {
+			return fmt.Errorf("marshal %T: %w", v, err)
+		}
+		_, err = e.w.Write(b)
+		return err
+	default:
+		return e.writeReflect(reflect.ValueOf(v))
+	}
+
+	return err
+}
+
+// writeReflect writes the types writeValue has no fast path for.
+func (e *encodeState) writeReflect(rv reflect.Value) error {
+	if !rv.IsValid() {
+		return fmt.Errorf("%w: <nil>", ErrTypeUnsupported)
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err := io.WriteString(e.w, "i"+strconv.FormatInt(rv.Int(), 10)+"e")
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, err := io.WriteString(e.w, "i"+strconv.FormatUint(rv.Uint(), 10)+"e")
+		return err
+	case reflect.String:
+		return WriteString(e.w, rv.String())
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return fmt.Errorf("%w: nil %s", ErrTypeUnsupported, rv.Type())
+		}
+		if rv.Kind() == reflect.Ptr {
+			if err := e.visit(rv); err != nil {
+				return err
+			}
+			defer e.unvisit(rv)
+		}
+		return e.writeValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return WriteString(e.w, string(b))
+		}
+
+		if err := e.enter(); err != nil {
+			return err
+		}
+		defer e.leave()
+
+		if _, err := io.WriteString(e.w, "l"); err != nil {
+			return err
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := e.writeValue(rv.Index(i).Interface()); err != nil {
+				return fmt.Errorf("list index %d: %w", i, err)
+			}
+		}
+		_, err := io.WriteString(e.w, "e")
+		return err
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("%w: %s", ErrTypeUnsupported, rv.Type())
+		}
+
+		if err := e.visit(rv); err != nil {
+			return err
+		}
+		defer e.unvisit(rv)
+		if err := e.enter(); err != nil {
+			return err
+		}
+		defer e.leave()
+
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+
+		if _, err := io.WriteString(e.w, "d"); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := WriteString(e.w, k); err != nil {
+				return err
+			}
+			v := rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()))
+			if err := e.writeValue(v.Interface()); err != nil {
+				return fmt.Errorf("dict key %q: %w", k, err)
+			}
+		}
+		_, err := io.WriteString(e.w, "e")
+		return err
+	case reflect.Struct:
+		return e.writeStruct(rv)
+	default:
+		return fmt.Errorf("%w: %s", ErrTypeUnsupported, rv.Type())
+	}
+}
+
+func (e *encodeState) writeStruct(rv reflect.Value) error {
+	if err := e.enter(); err != nil {
+		return err
+	}
+	defer e.leave()
+
+	fields := structFields(rv.Type())
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+
+	if _, err := io.WriteString(e.w, "d"); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		v, ok := fieldByIndex(rv, f.index, false)
+		if !ok || f.omitEmpty && isEmptyValue(v) || v.Kind() == reflect.Ptr && v.IsNil() {
+			continue
+		}
+
+		if err := WriteString(e.w, f.name); err != nil {
+			return err
+		}
+		if err := e.writeValue(v.Interface()); err != nil {
+			return fmt.Errorf("field %s: %w", f.fieldName, err)
+		}
+	}
+	_, err := io.WriteString(e.w, "e")
+
+	return err
+}
+
+// isEmptyValue tells whether v is left out by omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,949 @@
+package bencode
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteInt(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          int
+		expectedOut string
+	}{
+		{
+			name:        "0 is i0e",
+			in:          0,
+			expectedOut: "i0e",
+		},
+		{
+			name:        "90 is i90e",
+			in:          90,
+			expectedOut: "i90e",
+		},
+		{
+			name:        "-17 is i-17e",
+			in:          -17,
+			expectedOut: "i-17e",
+		},
+		{
+			name:        "the largest int",
+			in:          math.MaxInt,
+			expectedOut: "i" + strconv.Itoa(math.MaxInt) + "e",
+		},
+		{
+			name:        "the smallest int",
+			in:          math.MinInt,
+			expectedOut: "i" + strconv.Itoa(math.MinInt) + "e",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteInt(&buf, test.in)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedOut, buf.String())
+
+			i, err := ReadInt(bufio.NewReader(&buf))
+			assert.NoError(t, err)
+			assert.Equal(t, test.in, i)
+		})
+	}
+}
+
+func TestWriteString(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedOut string
+	}{
+		{
+			name:        "empty string is 0:",
+			in:          "",
+			expectedOut: "0:",
+		},
+		{
+			name:        "wiki is 4:wiki",
+			in:          "wiki",
+			expectedOut: "4:wiki",
+		},
+		{
+			name:        "length of a UTF-8 string is in bytes",
+			in:          "héllo",
+			expectedOut: "6:héllo",
+		},
+		{
+			name:        "binary string",
+			in:          "\x00:e\xff",
+			expectedOut: "4:\x00:e\xff",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteString(&buf, test.in)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedOut, buf.String())
+
+			s, err := ReadString(bufio.NewReader(&buf))
+			assert.NoError(t, err)
+			assert.Equal(t, test.in, s)
+		})
+	}
+}
+
+func TestWriteList(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          []interface{}
+		expectedOut string
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:        "valid: empty list is le",
+			in:          []interface{}{},
+			expectedOut: "le",
+		},
+		{
+			name:        "valid: list of strings",
+			in:          []interface{}{"spam", "eggs"},
+			expectedOut: "l4:spam4:eggse",
+		},
+		{
+			name:        "valid: mixed list",
+			in:          []interface{}{"spam", 42},
+			expectedOut: "l4:spami42ee",
+		},
+		{
+			name: "valid: nested lists and dicts",
+			in: []interface{}{
+				[]interface{}{1, []interface{}{}},
+				map[string]interface{}{"a": "b"},
+			},
+			expectedOut: "lli1eleed1:a1:bee",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: unsupported element",
+			in:          []interface{}{1, 2, 3.5},
+			expectedErr: "list index 2: unsupported type: float64",
+		},
+		{
+			name:        "invalid: unsupported element in a nested list",
+			in:          []interface{}{[]interface{}{nil}},
+			expectedErr: "list index 0: list index 0: unsupported type: <nil>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteList(&buf, test.in)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.ErrorIs(t, err, ErrTypeUnsupported)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedOut, buf.String())
+
+				l, err := ReadList(bufio.NewReader(&buf))
+				assert.NoError(t, err)
+				assert.Equal(t, test.in, l)
+			}
+		})
+	}
+}
+
+func TestWriteDictionary(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          map[string]interface{}
+		expectedOut string
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:        "valid: empty dict is de",
+			in:          map[string]interface{}{},
+			expectedOut: "de",
+		},
+		{
+			name:        "valid: keys are sorted",
+			in:          map[string]interface{}{"spam": "eggs", "cow": "moo"},
+			expectedOut: "d3:cow3:moo4:spam4:eggse",
+		},
+		{
+			name: "valid: nested values",
+			in: map[string]interface{}{
+				"info": map[string]interface{}{"length": 1, "name": "a"},
+				"list": []interface{}{1, "b"},
+			},
+			expectedOut: "d4:infod6:lengthi1e4:name1:ae4:listli1e1:bee",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: unsupported value",
+			in:          map[string]interface{}{"a": 1, "b": 1.5},
+			expectedErr: `dict key "b": unsupported type: float64`,
+		},
+		{
+			name: "invalid: unsupported value deep inside",
+			in: map[string]interface{}{
+				"info": map[string]interface{}{"files": []interface{}{true}},
+			},
+			expectedErr: `dict key "info": dict key "files": list index 0: unsupported type: bool`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteDictionary(&buf, test.in)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.ErrorIs(t, err, ErrTypeUnsupported)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedOut, buf.String())
+
+				d, err := ReadDictionary(bufio.NewReader(&buf))
+				assert.NoError(t, err)
+				assert.Equal(t, test.in, d)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          interface{}
+		expectedOut string
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:        "valid: int",
+			in:          42,
+			expectedOut: "i42e",
+		},
+		{
+			name:        "valid: negative int",
+			in:          -3,
+			expectedOut: "i-3e",
+		},
+		{
+			name:        "valid: string",
+			in:          "spam",
+			expectedOut: "4:spam",
+		},
+		{
+			name:        "valid: empty string",
+			in:          "",
+			expectedOut: "0:",
+		},
+		{
+			name:        "valid: list",
+			in:          []interface{}{"a", 1, []interface{}{}},
+			expectedOut: "l1:ai1elee",
+		},
+		{
+			name: "valid: dict with sorted keys",
+			in: map[string]interface{}{
+				"b": 1,
+				"a": map[string]interface{}{"c": "d"},
+			},
+			expectedOut: "d1:ad1:c1:de1:bi1ee",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: float",
+			in:          1.5,
+			expectedErr: "unsupported type: float64",
+		},
+		{
+			name:        "invalid: bool in a list",
+			in:          []interface{}{1, true},
+			expectedErr: "list index 1: unsupported type: bool",
+		},
+		{
+			name:        "invalid: nil",
+			in:          nil,
+			expectedErr: "unsupported type: <nil>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := Marshal(test.in)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.ErrorIs(t, err, ErrTypeUnsupported)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedOut, string(out))
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	in := "d8:announce3:url4:infod5:filesld6:lengthi5e4:pathl1:aeee4:name4:testee"
+
+	d, err := ReadDictionary(bufio.NewReader(bytes.NewReader([]byte(in))))
+	assert.NoError(t, err)
+
+	out, err := Marshal(d)
+	assert.NoError(t, err)
+	assert.Equal(t, in, string(out))
+}
+
+func TestMarshalKeyOrder(t *testing.T) {
+	d := map[string]interface{}{
+		"aa":    1,
+		"a\x00": 2,
+		"b":     3,
+		"a":     4,
+		"\xff":  5,
+		"A":     6,
+	}
+	expected := "d1:Ai6e1:ai4e2:a\x00i2e2:aai1e1:bi3e1:\xffi5ee"
+
+	// Map iteration order differs between runs,
+	// the output must not.
+	for i := 0; i < 10; i++ {
+		out, err := Marshal(d)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(out))
+	}
+}
+
+func TestEncodeEach(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          []interface{}
+		expectedN   int
+		expectedOut string
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:        "valid: nothing to write",
+			in:          nil,
+			expectedN:   0,
+			expectedOut: "",
+		},
+		{
+			name:        "valid: values are concatenated",
+			in:          []interface{}{1, "a", map[string]interface{}{}},
+			expectedN:   3,
+			expectedOut: "i1e1:ade",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: output stops after the last good value",
+			in:          []interface{}{1, []interface{}{2, 2.5}, 3},
+			expectedN:   1,
+			expectedOut: "i1e",
+			expectedErr: "value 1: list index 1: unsupported type: float64",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := EncodeEach(&buf, test.in)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedN, n)
+			assert.Equal(t, test.expectedOut, buf.String())
+		})
+	}
+}
+
+type testNode struct {
+	Name string    `bencode:"name"`
+	Next *testNode `bencode:"next,omitempty"`
+}
+
+func TestMarshalCycle(t *testing.T) {
+	m := map[string]interface{}{}
+	m["a"] = m
+
+	typed := map[string]map[string]interface{}{}
+	typed["a"] = map[string]interface{}{"b": typed}
+
+	node := &testNode{Name: "a"}
+	node.Next = &testNode{Name: "b", Next: node}
+
+	var self interface{}
+	self = &self
+
+	deep := []interface{}{}
+	for i := 0; i < DefaultMaxDepth; i++ {
+		deep = []interface{}{deep}
+	}
+
+	tests := []struct {
+		name        string
+		in          interface{}
+		expectedErr error
+	}{
+		{
+			name:        "invalid: a map in itself",
+			in:          m,
+			expectedErr: ErrCycle,
+		},
+		{
+			name:        "invalid: a typed map in itself",
+			in:          typed,
+			expectedErr: ErrCycle,
+		},
+		{
+			name:        "invalid: a pointer in itself",
+			in:          node,
+			expectedErr: ErrCycle,
+		},
+		{
+			name:        "invalid: a pointer to itself",
+			in:          self,
+			expectedErr: ErrCycle,
+		},
+		{
+			name:        "invalid: nested too deep",
+			in:          deep,
+			expectedErr: ErrMaxDepthExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Marshal(test.in)
+
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestMarshalSharedValue(t *testing.T) {
+	// The same map twice, side by side, is no cycle.
+	shared := map[string]interface{}{"a": 1}
+	node := &testNode{Name: "a"}
+
+	out, err := Marshal(map[string]interface{}{
+		"x": shared,
+		"y": shared,
+		"z": []*testNode{node, node},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "d1:xd1:ai1ee1:yd1:ai1ee1:zld4:name1:aed4:name1:aeee", string(out))
+
+	// As deep as decoding allows is fine.
+	deep := []interface{}{}
+	for i := 1; i < DefaultMaxDepth; i++ {
+		deep = []interface{}{deep}
+	}
+	out, err = Marshal(deep)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("l", DefaultMaxDepth)+strings.Repeat("e", DefaultMaxDepth), string(out))
+}
+
+var errWriteFailed = errors.New("write failed")
+
+// failingWriter takes n bytes, then fails every write.
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errWriteFailed
+	}
+	w.n -= len(p)
+
+	return len(p), nil
+}
+
+func TestWriteErrors(t *testing.T) {
+	list := []interface{}{"spam", 42}
+	dict := map[string]interface{}{"cow": "moo", "spam": list}
+	// Bigger than the buffer of an Encoder, so part of it
+	// is written while the rest is still being encoded.
+	big := struct {
+		Name   string `bencode:"name"`
+		Pieces []byte `bencode:"pieces"`
+	}{Name: "test", Pieces: make([]byte, 8192)}
+	raw := RawMessage("8192:" + string(make([]byte, 8192)))
+
+	tests := []struct {
+		name  string
+		n     int
+		write func(w io.Writer) error
+	}{
+		{
+			name:  "WriteInt",
+			n:     2,
+			write: func(w io.Writer) error { return WriteInt(w, 42) },
+		},
+		{
+			name:  "WriteString",
+			n:     3,
+			write: func(w io.Writer) error { return WriteString(w, "spam") },
+		},
+		{
+			name:  "WriteList: the opening l",
+			n:     0,
+			write: func(w io.Writer) error { return WriteList(w, list) },
+		},
+		{
+			name:  "WriteList: an element",
+			n:     len("l4:spam"),
+			write: func(w io.Writer) error { return WriteList(w, list) },
+		},
+		{
+			name:  "WriteList: the closing e",
+			n:     len("l4:spami42e"),
+			write: func(w io.Writer) error { return WriteList(w, list) },
+		},
+		{
+			name:  "WriteDictionary: the opening d",
+			n:     0,
+			write: func(w io.Writer) error { return WriteDictionary(w, dict) },
+		},
+		{
+			name:  "WriteDictionary: a key",
+			n:     len("d3:cow3:moo"),
+			write: func(w io.Writer) error { return WriteDictionary(w, dict) },
+		},
+		{
+			name:  "WriteDictionary: a value",
+			n:     len("d3:cow3:moo4:spaml4:spam"),
+			write: func(w io.Writer) error { return WriteDictionary(w, dict) },
+		},
+		{
+			name:  "WriteDictionary: the closing e",
+			n:     len("d3:cow3:moo4:spaml4:spami42ee"),
+			write: func(w io.Writer) error { return WriteDictionary(w, dict) },
+		},
+		{
+			name:  "Encoder.Encode",
+			n:     5,
+			write: func(w io.Writer) error { return NewEncoder(w).Encode(dict) },
+		},
+		{
+			name:  "Encoder.Encode: a struct Marshal takes",
+			n:     100,
+			write: func(w io.Writer) error { return NewEncoder(w).Encode(big) },
+		},
+		{
+			name:  "Encoder.Encode: a Marshaler",
+			n:     100,
+			write: func(w io.Writer) error { return NewEncoder(w).Encode(raw) },
+		},
+		{
+			name: "EncodeEach",
+			n:    len("i1e"),
+			write: func(w io.Writer) error {
+				_, err := EncodeEach(w, []interface{}{1, list})
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.write(&failingWriter{n: test.n})
+
+			assert.ErrorIs(t, err, errWriteFailed)
+		})
+	}
+}
+
+func TestMarshalStruct(t *testing.T) {
+	type file struct {
+		Length int64    `bencode:"length"`
+		Path   []string `bencode:"path"`
+	}
+	type info struct {
+		Name        string `bencode:"name"`
+		PieceLength uint32 `bencode:"piece length"`
+		Pieces      []byte `bencode:"pieces"`
+		Files       []file `bencode:"files,omitempty"`
+		Private     int    `bencode:"private,omitempty"`
+	}
+	type torrent struct {
+		Info     *info  `bencode:"info"`
+		Announce string `bencode:"announce"`
+		Comment  string `bencode:"comment,omitempty"`
+		Skipped  string `bencode:"-"`
+		Untagged int
+		hidden   int
+	}
+
+	tests := []struct {
+		name        string
+		in          interface{}
+		expectedOut string
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:        "valid: empty struct",
+			in:          struct{}{},
+			expectedOut: "de",
+		},
+		{
+			name: "valid: torrent with sorted keys",
+			in: torrent{
+				Info: &info{
+					Name:        "test",
+					PieceLength: 16384,
+					Pieces:      []byte{0, 1},
+					Files:       []file{{Length: 5, Path: []string{"a", "b"}}},
+				},
+				Announce: "url",
+				Skipped:  "x",
+				Untagged: 1,
+				hidden:   2,
+			},
+			expectedOut: "d8:Untaggedi1e8:announce3:url4:infod5:filesld6:lengthi5e4:pathl1:a1:beee" +
+				"4:name4:test12:piece lengthi16384e6:pieces2:\x00\x01ee",
+		},
+		{
+			name:        "valid: pointer to a struct",
+			in:          &file{Length: 1, Path: []string{}},
+			expectedOut: "d6:lengthi1e4:pathlee",
+		},
+		{
+			name:        "valid: typed map",
+			in:          map[string]int64{"b": 2, "a": 1},
+			expectedOut: "d1:ai1e1:bi2ee",
+		},
+		{
+			name:        "valid: nil pointer field is left out",
+			in:          torrent{},
+			expectedOut: "d8:Untaggedi0e8:announce0:e",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: nil interface field",
+			in:          struct{ V interface{} }{},
+			expectedErr: "field V: unsupported type: <nil>",
+		},
+		{
+			name:        "invalid: unsupported field type",
+			in:          struct{ F float32 }{},
+			expectedErr: "field F: unsupported type: float32",
+		},
+		{
+			name:        "invalid: map with int keys",
+			in:          map[int]string{},
+			expectedErr: "unsupported type: map[int]string",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := Marshal(test.in)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.ErrorIs(t, err, ErrTypeUnsupported)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedOut, string(out))
+			}
+		})
+	}
+}
+
+func TestMarshalOptionalFields(t *testing.T) {
+	type torrent struct {
+		Announce  string  `bencode:"announce"`
+		Comment   *string `bencode:"comment"`
+		CreatedBy *string `bencode:"created by"`
+		Date      *int    `bencode:"creation date"`
+	}
+
+	comment, date := "", 0
+
+	tests := []struct {
+		name        string
+		in          torrent
+		expectedOut string
+	}{
+		{
+			name:        "nil pointers have no key",
+			in:          torrent{Announce: "url"},
+			expectedOut: "d8:announce3:urle",
+		},
+		{
+			name:        "pointers to zero values are written",
+			in:          torrent{Announce: "url", Comment: &comment, Date: &date},
+			expectedOut: "d8:announce3:url7:comment0:13:creation datei0ee",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := Marshal(test.in)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedOut, string(out))
+
+			var back torrent
+			assert.NoError(t, Unmarshal(out, &back))
+			assert.Equal(t, test.in, back)
+		})
+	}
+}
+
+func TestMarshalEmbedded(t *testing.T) {
+	type Common struct {
+		Name    string `bencode:"name"`
+		Private int    `bencode:"private,omitempty"`
+	}
+	type Extra struct {
+		Source string `bencode:"source"`
+	}
+	type info struct {
+		Common
+		*Extra
+		Length int `bencode:"length"`
+	}
+	type tagged struct {
+		Common `bencode:"common"`
+	}
+
+	tests := []struct {
+		name        string
+		in          interface{}
+		expectedOut string
+	}{
+		{
+			name:        "fields are promoted",
+			in:          info{Common: Common{Name: "a"}, Extra: &Extra{Source: "b"}, Length: 1},
+			expectedOut: "d6:lengthi1e4:name1:a6:source1:be",
+		},
+		{
+			name:        "a nil embedded pointer has no fields",
+			in:          info{Common: Common{Name: "a"}},
+			expectedOut: "d6:lengthi0e4:name1:ae",
+		},
+		{
+			name:        "a tagged embedded struct is a dict",
+			in:          tagged{Common{Name: "a"}},
+			expectedOut: "d6:commond4:name1:aee",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := Marshal(test.in)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedOut, string(out))
+		})
+	}
+
+	var back info
+	assert.NoError(t, Unmarshal([]byte("d6:lengthi1e4:name1:a6:source1:be"), &back))
+	assert.Equal(t, info{Common: Common{Name: "a"}, Extra: &Extra{Source: "b"}, Length: 1}, back)
+}
+
+func TestMarshalKeyConflicts(t *testing.T) {
+	type inner struct {
+		X int `bencode:"x"`
+		Y int
+	}
+	type other struct {
+		X int `bencode:"x"`
+	}
+	type taggedY struct {
+		Z int `bencode:"Y"`
+	}
+
+	tests := []struct {
+		name        string
+		in          interface{}
+		expectedOut string
+	}{
+		{
+			name: "two fields with the same tag cancel out",
+			in: struct {
+				A int `bencode:"x"`
+				B int `bencode:"x"`
+				C int
+			}{A: 1, B: 2, C: 3},
+			expectedOut: "d1:Ci3ee",
+		},
+		{
+			name: "the tagged field wins",
+			in: struct {
+				A int `bencode:"Y"`
+				Y int
+			}{A: 1, Y: 2},
+			expectedOut: "d1:Yi1ee",
+		},
+		{
+			name: "the outer field wins",
+			in: struct {
+				inner
+				X int `bencode:"x"`
+			}{inner: inner{X: 1, Y: 2}, X: 3},
+			expectedOut: "d1:Yi2e1:xi3ee",
+		},
+		{
+			name: "fields at the same depth cancel out",
+			in: struct {
+				inner
+				other
+			}{inner: inner{X: 1, Y: 2}, other: other{X: 3}},
+			expectedOut: "d1:Yi2ee",
+		},
+		{
+			name: "a tagged field at the same depth wins",
+			in: struct {
+				inner
+				taggedY
+			}{inner: inner{X: 1, Y: 2}, taggedY: taggedY{Z: 3}},
+			expectedOut: "d1:Yi3e1:xi1ee",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := Marshal(test.in)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedOut, string(out))
+		})
+	}
+}
+
+func TestMarshalStructRoundTrip(t *testing.T) {
+	length := 7
+	in := testTorrent{
+		Announce: "url",
+		Info: testInfo{
+			Name:        "test",
+			PieceLength: 16384,
+			Length:      &length,
+			Private:     1,
+			Files:       []interface{}{"a"},
+		},
+		Other: "x",
+	}
+
+	data, err := Marshal(in)
+	assert.NoError(t, err)
+
+	var out testTorrent
+	assert.NoError(t, Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+}
+
+// testUnixTime encodes itself as seconds since the epoch.
+type testUnixTime struct {
+	sec int64
+}
+
+func (t testUnixTime) MarshalBencode() ([]byte, error) {
+	if t.sec < 0 {
+		return nil, errors.New("before the epoch")
+	}
+	return []byte("i" + strconv.FormatInt(t.sec, 10) + "e"), nil
+}
+
+// testID encodes itself as a fixed-size hex string.
+type testID [4]byte
+
+func (id *testID) MarshalBencode() ([]byte, error) {
+	return []byte(fmt.Sprintf("8:%x", id[:])), nil
+}
+
+func TestMarshaler(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          interface{}
+		expectedOut string
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:        "valid: value receiver",
+			in:          testUnixTime{sec: 1700000000},
+			expectedOut: "i1700000000e",
+		},
+		{
+			name:        "valid: pointer receiver",
+			in:          &testID{0xde, 0xad, 0xbe, 0xef},
+			expectedOut: "8:deadbeef",
+		},
+		{
+			name: "valid: struct fields and list elements",
+			in: struct {
+				Created testUnixTime   `bencode:"creation date"`
+				Times   []testUnixTime `bencode:"times"`
+			}{
+				Created: testUnixTime{sec: 1},
+				Times:   []testUnixTime{{sec: 2}},
+			},
+			expectedOut: "d13:creation datei1e5:timesli2eee",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: MarshalBencode fails",
+			in:          []interface{}{testUnixTime{sec: -1}},
+			expectedErr: "list index 0: marshal bencode.testUnixTime: before the epoch",
+		},
+		{
+			name:        "invalid: nil pointer",
+			in:          (*testID)(nil),
+			expectedErr: "unsupported type: nil *bencode.testID",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := Marshal(test.in)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedOut, string(b))
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package bencode
+
+import (
+	"bufio"
+	"io"
+)
+
+// Encoder writes bencoded values to an output stream.
+type Encoder struct {
+	out io.Writer
+	w   *bufio.Writer
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{out: w, w: bufio.NewWriter(w)}
+}
+
+// Encode writes the bencoding of v to the stream.
+//
+// v may be of any type Marshal accepts. Output is buffered and
+// flushed once the whole value has been encoded. When v can't be
+// encoded whatever is still buffered is dropped, but a part of
+// a value larger than the buffer may have been written already.
+func (e *Encoder) Encode(v interface{}) error {
+	if err := (&encodeState{w: e.w}).writeValue(v); err != nil {
+		e.w.Reset(e.out)
+		return err
+	}
+
+	return e.w.Flush()
+}
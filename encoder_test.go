@@ -0,0 +1,35 @@
+package bencode
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+
+	assert.NoError(t, e.Encode(1))
+	// Flushed after every value.
+	assert.Equal(t, "i1e", buf.String())
+
+	assert.NoError(t, e.Encode(map[string]interface{}{"a": []interface{}{"b"}}))
+	assert.Equal(t, "i1ed1:al1:bee", buf.String())
+
+	// A failed value leaves no trace in the output.
+	err := e.Encode([]interface{}{1, 1.5})
+	assert.ErrorIs(t, err, ErrTypeUnsupported)
+	assert.NoError(t, e.Encode("c"))
+	assert.Equal(t, "i1ed1:al1:bee1:c", buf.String())
+
+	// The stream reads back value by value.
+	r := bufio.NewReader(&buf)
+	for _, expected := range []interface{}{1, map[string]interface{}{"a": []interface{}{"b"}}, "c"} {
+		v, err := ReadValue(r)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, v)
+	}
+}
@@ -0,0 +1,143 @@
+package bencode
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"reflect"
+)
+
+// StreamEqual reads one value from each of a and b and reports
+// whether they are equal, without building either of them in memory.
+//
+// Both streams are scanned in lockstep, a token at a time, and
+// the comparison stops at the first difference, including a difference
+// in shape. Values are compared by what they mean, so i01e equals i1e,
+// but dictionaries are compared in the order their keys come in,
+// which for valid bencode is the sorted one anyway.
+//
+// An error is returned when either stream is not valid bencode
+// up to the point where they differ.
+func StreamEqual(a, b io.Reader) (bool, error) {
+	sa, sb := NewScanner(a), NewScanner(b)
+	for {
+		ta, err := sa.Next()
+		if err != nil {
+			return false, err
+		}
+		tb, err := sb.Next()
+		if err != nil {
+			return false, err
+		}
+
+		if ta.Kind != tb.Kind || ta.Int != tb.Int || !bytes.Equal(ta.Bytes, tb.Bytes) {
+			return false, nil
+		}
+		if len(sa.open) == 0 {
+			return true, nil
+		}
+	}
+}
+
+// Equal reports whether two decoded values mean the same bencode,
+// no matter which Go types they were decoded into: ints are equal
+// by value whatever their width, *big.Int included, strings and
+// []byte are compared by their bytes, and lists and dictionaries,
+// typed slices and maps with string keys included, element by element.
+//
+// Dictionaries are Go maps, which have no order, so the order of
+// the keys in the input they were decoded from doesn't matter.
+//
+// Example:
+// map[string]interface{}{"a": []byte("x"), "b": int64(1)}
+// equals map[string]interface{}{"b": 1, "a": "x"}.
+func Equal(a, b interface{}) bool {
+	return valuesEqual(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func valuesEqual(a, b reflect.Value) bool {
+	a, b = underlying(a), underlying(b)
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+
+	if ia, ok := bigInt(a); ok {
+		ib, ok := bigInt(b)
+		return ok && ia.Cmp(ib) == 0
+	}
+	if sa, ok := byteString(a); ok {
+		sb, ok := byteString(b)
+		return ok && bytes.Equal(sa, sb)
+	}
+
+	switch a.Kind() {
+	case reflect.Slice, reflect.Array:
+		if b.Kind() != reflect.Slice && b.Kind() != reflect.Array || a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !valuesEqual(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if b.Kind() != reflect.Map || a.Len() != b.Len() ||
+			a.Type().Key().Kind() != reflect.String || b.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		for _, k := range a.MapKeys() {
+			vb := b.MapIndex(k.Convert(b.Type().Key()))
+			if !vb.IsValid() || !valuesEqual(a.MapIndex(k), vb) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// underlying unwraps interfaces and pointers, except for *big.Int.
+func underlying(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.Type() == bigIntType {
+			return v
+		}
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+var bigIntType = reflect.TypeOf((*big.Int)(nil))
+
+// bigInt returns the value of an int of any kind.
+func bigInt(v reflect.Value) (*big.Int, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(v.Uint()), true
+	}
+	if v.Type() == bigIntType {
+		return v.Interface().(*big.Int), true
+	}
+
+	return nil, false
+}
+
+// byteString returns the bytes of a string or a []byte.
+func byteString(v reflect.Value) ([]byte, bool) {
+	switch {
+	case v.Kind() == reflect.String:
+		return []byte(v.String()), true
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return v.Bytes(), true
+	}
+
+	return nil, false
+}
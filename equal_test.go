@@ -0,0 +1,241 @@
+package bencode
+
+import (
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamEqual(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+
+	tests := []struct {
+		name          string
+		a             string
+		b             string
+		expectedEqual bool
+		expectedErr   error
+	}{
+		// Equal
+		{
+			name:          "equal: ints",
+			a:             "i1e",
+			b:             "i1e",
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: same int, different encoding",
+			a:             "i01e",
+			b:             "i1e",
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: nested",
+			a:             "d1:ali1e1:bee1:bdee",
+			b:             "d1:ali1e1:bee1:bdee",
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: strings longer than the buffer",
+			a:             "10000:" + long,
+			b:             "10000:" + long,
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: whatever follows the value is ignored",
+			a:             "i1ejunk",
+			b:             "i1e",
+			expectedEqual: true,
+		},
+
+		// Not equal
+		{
+			name: "not equal: different ints",
+			a:    "i1e",
+			b:    "i2e",
+		},
+		{
+			name: "not equal: different types",
+			a:    "i1e",
+			b:    "1:1",
+		},
+		{
+			name: "not equal: list is longer",
+			a:    "li1ei2ee",
+			b:    "li1ee",
+		},
+		{
+			name: "not equal: strings of different length",
+			a:    "2:ab",
+			b:    "3:abc",
+		},
+		{
+			name: "not equal: strings differ at the end",
+			a:    "10000:" + long[1:] + "y",
+			b:    "10000:" + long,
+		},
+		{
+			name: "not equal: difference before broken input",
+			a:    "li1ei2e",
+			b:    "li3e",
+		},
+
+		// Errors
+		{
+			name:        "invalid: a is empty",
+			a:           "",
+			b:           "i1e",
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "invalid: both are broken the same way",
+			a:           "li1e",
+			b:           "li1e",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: not a value",
+			a:           "x",
+			b:           "i1e",
+			expectedErr: ErrValueInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			eq, err := StreamEqual(strings.NewReader(test.a), strings.NewReader(test.b))
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedEqual, eq)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	huge, _ := new(big.Int).SetString("18446744073709551616", 10)
+
+	tests := []struct {
+		name          string
+		a             interface{}
+		b             interface{}
+		expectedEqual bool
+	}{
+		// Equal
+		{
+			name:          "equal: ints of different widths",
+			a:             int64(-1),
+			b:             int8(-1),
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: int and uint",
+			a:             uint64(1),
+			b:             1,
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: int and *big.Int",
+			a:             big.NewInt(42),
+			b:             int32(42),
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: string and []byte",
+			a:             "spam",
+			b:             []byte("spam"),
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: typed and untyped lists",
+			a:             []interface{}{"a", 1},
+			b:             []interface{}{[]byte("a"), int64(1)},
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: typed slice",
+			a:             []string{"a", "b"},
+			b:             []interface{}{"a", []byte("b")},
+			expectedEqual: true,
+		},
+		{
+			name: "equal: nested dicts",
+			a: map[string]interface{}{
+				"a": []interface{}{1},
+				"b": map[string]interface{}{"c": "d"},
+			},
+			b: map[string]interface{}{
+				"b": map[string]string{"c": "d"},
+				"a": []int{1},
+			},
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: keys without a value",
+			a:             map[string]interface{}{"a": nil},
+			b:             map[string]interface{}{"a": nil},
+			expectedEqual: true,
+		},
+		{
+			name:          "equal: empty list and nil slice",
+			a:             []interface{}{},
+			b:             []int(nil),
+			expectedEqual: true,
+		},
+
+		// Not equal
+		{
+			name: "not equal: ints",
+			a:    1,
+			b:    2,
+		},
+		{
+			name: "not equal: uint64 above the int64 range",
+			a:    uint64(1 << 63),
+			b:    int64(-1 << 63),
+		},
+		{
+			name: "not equal: big int",
+			a:    huge,
+			b:    uint64(0),
+		},
+		{
+			name: "not equal: int and its string",
+			a:    1,
+			b:    "1",
+		},
+		{
+			name: "not equal: list lengths",
+			a:    []interface{}{1},
+			b:    []interface{}{1, 2},
+		},
+		{
+			name: "not equal: list and dict",
+			a:    []interface{}{},
+			b:    map[string]interface{}{},
+		},
+		{
+			name: "not equal: missing key",
+			a:    map[string]interface{}{"a": 1},
+			b:    map[string]interface{}{"b": 1},
+		},
+		{
+			name: "not equal: nil and a value",
+			a:    map[string]interface{}{"a": nil},
+			b:    map[string]interface{}{"a": 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedEqual, Equal(test.a, test.b))
+			assert.Equal(t, test.expectedEqual, Equal(test.b, test.a))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package bencode
+
+import (
+	"errors"
+	"io"
+)
+
+// malformedErrors are the errors which mean the input is broken
+// for good, no matter how much more of it arrives.
+var malformedErrors = []error{
+	ErrDictInvalid,
+	ErrListInvalid,
+	ErrIntInvalid,
+	ErrStringInvalid,
+	ErrValueInvalid,
+	ErrFrameInvalid,
+	ErrTrailingData,
+	ErrDuplicateKey,
+	ErrKeysNotSorted,
+	ErrMaxDepthExceeded,
+	ErrStringTooLong,
+}
+
+// IsTruncated reports whether err means the input ended before
+// the value did, so retrying with more data may succeed.
+func IsTruncated(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsMalformed reports whether err means the input is not valid bencode
+// and should be rejected. A truncated input is never malformed,
+// even when it ends in the middle of an int or a string.
+func IsMalformed(err error) bool {
+	if err == nil || IsTruncated(err) {
+		return false
+	}
+	for _, target := range malformedErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
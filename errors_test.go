@@ -0,0 +1,122 @@
+package bencode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name              string
+		err               error
+		expectedTruncated bool
+		expectedMalformed bool
+	}{
+		{
+			name: "nil is neither",
+			err:  nil,
+		},
+		{
+			name:              "io.EOF is truncated",
+			err:               io.EOF,
+			expectedTruncated: true,
+		},
+		{
+			name:              "io.ErrUnexpectedEOF is truncated",
+			err:               io.ErrUnexpectedEOF,
+			expectedTruncated: true,
+		},
+		{
+			name:              "ErrIntInvalid is malformed",
+			err:               ErrIntInvalid,
+			expectedMalformed: true,
+		},
+		{
+			name:              "wrapped ErrDictInvalid is malformed",
+			err:               fmt.Errorf("info: %w", ErrDictInvalid),
+			expectedMalformed: true,
+		},
+		{
+			name:              "ErrTrailingData is malformed",
+			err:               fmt.Errorf("%w: 3 bytes after the value", ErrTrailingData),
+			expectedMalformed: true,
+		},
+		{
+			name:              "ErrDuplicateKey is malformed",
+			err:               &SyntaxError{Offset: 7, Err: ErrDuplicateKey},
+			expectedMalformed: true,
+		},
+		{
+			name:              "ErrKeysNotSorted is malformed",
+			err:               &SyntaxError{Offset: 7, Err: ErrKeysNotSorted},
+			expectedMalformed: true,
+		},
+		{
+			name:              "ErrMaxDepthExceeded is malformed",
+			err:               &SyntaxError{Offset: 100, Err: ErrMaxDepthExceeded},
+			expectedMalformed: true,
+		},
+		{
+			name:              "ErrStringTooLong is malformed",
+			err:               &SyntaxError{Offset: 0, Err: ErrStringTooLong},
+			expectedMalformed: true,
+		},
+		{
+			name: "unrelated error is neither",
+			err:  errors.New("boom"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedTruncated, IsTruncated(test.err))
+			assert.Equal(t, test.expectedMalformed, IsMalformed(test.err))
+		})
+	}
+}
+
+func TestErrorClassificationFromReaders(t *testing.T) {
+	_, err := ReadDictionary(bufio.NewReader(strings.NewReader("d1:ad")))
+	assert.True(t, IsTruncated(err))
+	assert.False(t, IsMalformed(err))
+
+	_, err = ReadInt(bufio.NewReader(strings.NewReader("i12")))
+	assert.True(t, IsTruncated(err))
+	assert.False(t, IsMalformed(err))
+
+	_, err = ReadString(bufio.NewReader(strings.NewReader("5:ab")))
+	assert.True(t, IsTruncated(err))
+	assert.False(t, IsMalformed(err))
+
+	_, err = ReadList(bufio.NewReader(strings.NewReader("lxe")))
+	assert.False(t, IsTruncated(err))
+	assert.True(t, IsMalformed(err))
+
+	d := NewDecoder(strings.NewReader("d1:ai1e1:ai2ee"))
+	d.RejectDuplicateKeys = true
+	_, err = d.Decode()
+	assert.False(t, IsTruncated(err))
+	assert.True(t, IsMalformed(err))
+
+	d = NewDecoder(strings.NewReader("d1:bi1e1:ai2ee"))
+	d.RejectUnsortedKeys = true
+	_, err = d.Decode()
+	assert.False(t, IsTruncated(err))
+	assert.True(t, IsMalformed(err))
+
+	_, err = ReadList(bufio.NewReader(strings.NewReader(strings.Repeat("l", DefaultMaxDepth+1))))
+	assert.False(t, IsTruncated(err))
+	assert.True(t, IsMalformed(err))
+
+	d = NewDecoder(strings.NewReader("10:abc"))
+	d.MaxStringLength = 5
+	_, err = d.Decode()
+	assert.False(t, IsTruncated(err))
+	assert.True(t, IsMalformed(err))
+}
@@ -0,0 +1,208 @@
+package bencode
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// field is a struct field as seen through its bencode tag.
+type field struct {
+	name string
+	// fieldName is the Go name of the field, for errors and Presence.
+	fieldName string
+	// index leads to the field through the embedded structs
+	// it's promoted from, as in reflect.Value.FieldByIndex.
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	// min and max are the bounds of an int field, bencode:"name,min=1,max=9",
+	// as they are in the tag. Empty when there are none.
+	min, max string
+}
+
+// structFields lists the fields of t which take part in encoding.
+//
+// The key of a field comes from its tag, bencode:"name", and falls back
+// to the field name when the tag has none. Fields tagged bencode:"-",
+// unexported fields, Presence fields and raw fields are left out.
+//
+// The fields of an embedded struct without a tag name are promoted
+// into t, the way encoding/json does it. When several fields have the
+// same key, the least deeply embedded one wins, then the tagged one.
+// If that leaves more than one, none of them is used.
+func structFields(t reflect.Type) []field {
+	var all []field
+	collectFields(t, nil, map[reflect.Type]bool{t: true}, &all)
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].name != all[j].name {
+			return all[i].name < all[j].name
+		}
+		if len(all[i].index) != len(all[j].index) {
+			return len(all[i].index) < len(all[j].index)
+		}
+		return all[i].tagged && !all[j].tagged
+	})
+
+	fields := make([]field, 0, len(all))
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].name == all[i].name {
+			j++
+		}
+		if f, ok := dominantField(all[i:j]); ok {
+			fields = append(fields, f)
+		}
+		i = j
+	}
+
+	// Back to the order the fields are declared in.
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	return fields
+}
+
+// collectFields appends the fields of t, found at index, to all.
+// seen holds the structs on the way to t, which stops a struct
+// embedding itself through a pointer.
+func collectFields(t reflect.Type, index []int, seen map[reflect.Type]bool, all *[]field) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		embedded := sf.Anonymous && ft.Kind() == reflect.Struct
+		// An unexported embedded struct may still have exported fields.
+		if sf.PkgPath != "" && !embedded || sf.Type == presenceType {
+			continue
+		}
+
+		tag := sf.Tag.Get("bencode")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if hasOption(opts, "raw") {
+			continue
+		}
+		if name == "" && embedded {
+			if !seen[ft] {
+				seen[ft] = true
+				collectFields(ft, append(index[:len(index):len(index)], i), seen, all)
+				delete(seen, ft)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		f := field{
+			name:      name,
+			fieldName: sf.Name,
+			index:     append(index[:len(index):len(index)], i),
+			tagged:    name != "",
+		}
+		if name == "" {
+			f.name = sf.Name
+		}
+		f.omitEmpty = hasOption(opts, "omitempty")
+		f.min, f.max = option(opts, "min"), option(opts, "max")
+		*all = append(*all, f)
+	}
+}
+
+// dominantField picks the field which gets a key out of the fields
+// which share it, sorted by depth, tagged ones first.
+func dominantField(fields []field) (field, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) &&
+		fields[0].tagged == fields[1].tagged {
+		return field{}, false
+	}
+
+	return fields[0], true
+}
+
+// fieldByIndex returns the field of rv at index. It's not there when
+// one of the embedded structs on the way is a nil pointer, unless alloc
+// makes it allocate them.
+func fieldByIndex(rv reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for k, i := range index {
+		if k > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !alloc || !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(i)
+	}
+
+	return rv, true
+}
+
+// hasOption tells whether the options of a tag, what follows
+// the name, include o.
+func hasOption(opts, o string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == o {
+			return true
+		}
+	}
+
+	return false
+}
+
+// option returns the value of the option o=value in the options of a tag.
+func option(opts, o string) string {
+	for _, opt := range strings.Split(opts, ",") {
+		if v, ok := strings.CutPrefix(opt, o+"="); ok {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// rawField returns the index of the first exported field of t tagged
+// bencode:",raw" which is a RawMessage, a []byte or the like.
+func rawField(t reflect.Type) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" || sf.Type.Kind() != reflect.Slice || sf.Type.Elem().Kind() != reflect.Uint8 {
+			continue
+		}
+		if _, opts, _ := strings.Cut(sf.Tag.Get("bencode"), ","); hasOption(opts, "raw") {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+var presenceType = reflect.TypeOf(Presence(nil))
+
+// presenceField returns the index of the first exported Presence field of t.
+func presenceField(t reflect.Type) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if sf := t.Field(i); sf.PkgPath == "" && sf.Type == presenceType {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,75 @@
+package bencode
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// ErrFrameInvalid is returned when a length-prefixed frame
+// doesn't hold exactly one value.
+var ErrFrameInvalid error = errors.New("invalid frame")
+
+// ReadLengthPrefixed reads a value framed as
+// <length of value>:<value>
+//
+// Example:
+// 4:i42e
+// is a frame holding an int 42.
+//
+// The frame has to hold exactly one value: a value that ends before
+// the frame does or that runs past it is an ErrFrameInvalid.
+// A frame cut short by the end of input, the prefix included,
+// is an io.ErrUnexpectedEOF, while an input that ends before
+// the frame starts is io.EOF.
+func ReadLengthPrefixed(r *bufio.Reader) (interface{}, error) {
+	if _, err := r.Peek(1); err != nil {
+		return nil, err
+	}
+	l, err := r.ReadSlice(stringSeparator)
+	if err == io.EOF {
+		// Only digits so far means the input ended inside the prefix.
+		if _, err := parseLength(l); err == nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, ErrFrameInvalid
+	}
+	if err == bufio.ErrBufferFull {
+		return nil, ErrFrameInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	length, err := parseLength(l[:len(l)-1])
+	if err != nil {
+		return nil, ErrFrameInvalid
+	}
+
+	// The length comes from the input, so the frame is read
+	// by readBody, which only grows it as its bytes arrive.
+	frame, err := readBody(r, length)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeFrame(frame)
+}
+
+// decodeFrame decodes the value a frame holds.
+func decodeFrame(frame []byte) (interface{}, error) {
+	v, rest, err := DecodeOne(frame)
+	if errors.Is(err, io.EOF) {
+		return nil, ErrFrameInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrFrameInvalid
+	}
+
+	return v, nil
+}
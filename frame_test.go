@@ -0,0 +1,102 @@
+package bencode
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLengthPrefixed(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: int",
+			in:            "4:i42e",
+			expectedValue: 42,
+		},
+		{
+			name:          "valid: dict",
+			in:            "8:d1:ai1ee",
+			expectedValue: map[string]interface{}{"a": 1},
+		},
+		{
+			name:          "valid: bytes after the frame are left alone",
+			in:            "3:i1ei2e",
+			expectedValue: 1,
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: no length",
+			in:          "i1e",
+			expectedErr: ErrFrameInvalid,
+		},
+		{
+			name:        "invalid: value is shorter than the frame",
+			in:          "4:i1ee",
+			expectedErr: ErrFrameInvalid,
+		},
+		{
+			name:        "invalid: value runs past the frame",
+			in:          "4:li1ei2ee",
+			expectedErr: ErrFrameInvalid,
+		},
+		{
+			name:        "invalid: int runs past the frame",
+			in:          "3:li1ee",
+			expectedErr: ErrFrameInvalid,
+		},
+		{
+			name:        "invalid: empty frame",
+			in:          "0:",
+			expectedErr: ErrFrameInvalid,
+		},
+		{
+			name:        "invalid: input ends inside the frame",
+			in:          "10:i1e",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: input ends inside the prefix",
+			in:          "10",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: huge length",
+			in:          "999999999999999999:",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: no frame",
+			in:          "",
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "invalid: malformed value",
+			in:          "3:ixe",
+			expectedErr: ErrIntInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			v, err := ReadLengthPrefixed(r)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
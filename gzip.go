@@ -0,0 +1,31 @@
+package bencode
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// DecodeMaybeCompressed reads a single value from r, which may be gzipped.
+//
+// The first two bytes are only peeked at: when they are the gzip magic
+// the stream is decompressed first, otherwise it's decoded as is.
+func DecodeMaybeCompressed(r io.Reader) (interface{}, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(len(gzipMagic))
+	if err == nil && bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		br = bufio.NewReader(gz)
+	}
+
+	return ReadValue(br)
+}
@@ -0,0 +1,76 @@
+package bencode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func TestDecodeMaybeCompressed(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            []byte
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: plain dict",
+			in:            []byte("d1:ai1ee"),
+			expectedValue: map[string]interface{}{"a": 1},
+		},
+		{
+			name:          "valid: gzipped dict",
+			in:            gzipped(t, "d1:ai1ee"),
+			expectedValue: map[string]interface{}{"a": 1},
+		},
+		{
+			name:          "valid: plain one-byte input isn't mistaken for gzip",
+			in:            []byte("0:"),
+			expectedValue: "",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: empty input",
+			in:          []byte{},
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "invalid: gzipped garbage",
+			in:          gzipped(t, "5:ab"),
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: truncated gzip header",
+			in:          []byte{0x1f, 0x8b, 0x08},
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := DecodeMaybeCompressed(bytes.NewReader(test.in))
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package bencode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// BinaryEncoding is how ToJSONWith writes strings which are not UTF-8.
+type BinaryEncoding int
+
+const (
+	// Base64 writes binary strings in standard base64, with padding.
+	Base64 BinaryEncoding = iota
+	// Hex writes binary strings in lower case hex.
+	Hex
+)
+
+// JSONOptions tunes what ToJSONWith produces.
+type JSONOptions struct {
+	// Binary is the encoding of strings which are not valid UTF-8,
+	// like the pieces of a torrent. Base64 by default.
+	Binary BinaryEncoding
+}
+
+// ToJSON converts a decoded value to JSON, e.g. to look into a torrent
+// with jq. Dictionary keys come out sorted, so the same value always
+// gives the same output.
+//
+// Strings which are not valid UTF-8 are written in base64, which keeps
+// the output valid JSON. Note that nothing tells such a string from
+// a text that happens to look like base64.
+//
+// Example:
+// d4:name4:test6:pieces2:\x00\xffe
+// gives {"name":"test","pieces":"AP8="}.
+func ToJSON(v interface{}) ([]byte, error) {
+	return ToJSONWith(v, JSONOptions{})
+}
+
+// ToJSONWith is ToJSON with options.
+func ToJSONWith(v interface{}, o JSONOptions) ([]byte, error) {
+	j, err := toJSONValue(v, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(j); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// toJSONValue converts v to what encoding/json writes the way ToJSON
+// wants it. Maps are written with sorted keys by encoding/json itself.
+func toJSONValue(v interface{}, o JSONOptions) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, int:
+		return v, nil
+	case string:
+		return o.text([]byte(v)), nil
+	case []byte:
+		return o.text(v), nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			j, err := toJSONValue(e, o)
+			if err != nil {
+				return nil, fmt.Errorf("list index %d: %w", i, err)
+			}
+			l[i] = j
+		}
+		return l, nil
+	case map[string]interface{}:
+		d := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			j, err := toJSONValue(e, o)
+			if err != nil {
+				return nil, fmt.Errorf("dict key %q: %w", k, err)
+			}
+			d[o.text([]byte(k))] = j
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrTypeUnsupported, v)
+	}
+}
+
+// text returns b as it is if it's valid UTF-8 and encoded otherwise.
+func (o JSONOptions) text(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	if o.Binary == Hex {
+		return hex.EncodeToString(b)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
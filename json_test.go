@@ -0,0 +1,86 @@
+package bencode
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           interface{}
+		options      JSONOptions
+		expectedJSON string
+		expectedErr  string
+	}{
+		// Positive cases
+		{
+			name: "valid: torrent with binary pieces",
+			in: map[string]interface{}{
+				"name":         "test",
+				"piece length": 16384,
+				"pieces":       "\x00\xff",
+				"files":        []interface{}{map[string]interface{}{"length": 1}},
+			},
+			expectedJSON: `{"files":[{"length":1}],"name":"test","piece length":16384,"pieces":"AP8="}`,
+		},
+		{
+			name:         "valid: hex",
+			in:           []interface{}{"\x00\xff", "ok"},
+			options:      JSONOptions{Binary: Hex},
+			expectedJSON: `["00ff","ok"]`,
+		},
+		{
+			name:         "valid: []byte values and binary keys",
+			in:           map[string]interface{}{"\xff": []byte("text")},
+			expectedJSON: `{"/w==":"text"}`,
+		},
+		{
+			name:         "valid: UTF-8 and HTML are kept",
+			in:           "héllo <&>",
+			expectedJSON: `"héllo <&>"`,
+		},
+		{
+			name:         "valid: key without a value",
+			in:           map[string]interface{}{"a": nil},
+			expectedJSON: `{"a":null}`,
+		},
+		{
+			name:         "valid: empty list and dict",
+			in:           []interface{}{[]interface{}{}, map[string]interface{}{}},
+			expectedJSON: `[[],{}]`,
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: unsupported type",
+			in:          map[string]interface{}{"a": []interface{}{1.5}},
+			expectedErr: `dict key "a": list index 0: unsupported type: float64`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := ToJSONWith(test.in, test.options)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedJSON, string(b))
+			}
+		})
+	}
+}
+
+func TestToJSONFromReadValue(t *testing.T) {
+	v, err := ReadValue(bufio.NewReader(strings.NewReader("d4:name4:test6:pieces2:\x00\xffe")))
+	assert.NoError(t, err)
+
+	b, err := ToJSON(v)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"test","pieces":"AP8="}`, string(b))
+}
@@ -0,0 +1,84 @@
+package bencode
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LazyDict is a dictionary whose values are decoded on first access.
+//
+// Only the keys and the extent of each value are found up front,
+// so a big torrent costs next to nothing until its files or pieces
+// are actually asked for. A LazyDict is not safe for concurrent use.
+type LazyDict struct {
+	raw   map[string][]byte
+	cache map[string]interface{}
+}
+
+// NewLazyDict splits data, which has to hold exactly one dictionary,
+// into its keys and raw values.
+func NewLazyDict(data []byte) (*LazyDict, error) {
+	d := &LazyDict{
+		raw:   make(map[string][]byte),
+		cache: make(map[string]interface{}),
+	}
+
+	w := &walker{data: data}
+	n, err := w.entries(0, 0, func(key []byte, start, end int) error {
+		// A key without a value gets a nil one.
+		var v []byte
+		if start != end {
+			v = data[start:end]
+		}
+		d.raw[string(key)] = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if n != len(data) {
+		return nil, fmt.Errorf("%w: %d bytes after the value", ErrTrailingData, len(data)-n)
+	}
+
+	return d, nil
+}
+
+// Keys returns the keys of the dictionary in sorted order.
+func (d *LazyDict) Keys() []string {
+	keys := make([]string, 0, len(d.raw))
+	for k := range d.raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Raw returns the raw bytes of the value under key.
+func (d *LazyDict) Raw(key string) ([]byte, bool) {
+	v, ok := d.raw[key]
+	return v, ok
+}
+
+// Get decodes the value under key the first time it's asked for
+// and returns the cached result afterwards.
+func (d *LazyDict) Get(key string) (interface{}, bool, error) {
+	if v, ok := d.cache[key]; ok {
+		return v, true, nil
+	}
+	raw, ok := d.raw[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if raw == nil {
+		return nil, true, nil
+	}
+
+	v, _, err := DecodeOne(raw)
+	if err != nil {
+		return nil, true, err
+	}
+	d.cache[key] = v
+
+	return v, true, nil
+}
@@ -0,0 +1,99 @@
+package bencode
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazyDict(t *testing.T) {
+	d, err := NewLazyDict([]byte("d5:filesld6:lengthi5eee4:name4:test6:pieces2:\x00\x011:xe"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"files", "name", "pieces", "x"}, d.Keys())
+
+	raw, ok := d.Raw("files")
+	assert.True(t, ok)
+	assert.Equal(t, "ld6:lengthi5eee", string(raw))
+
+	v, ok, err := d.Get("files")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{map[string]interface{}{"length": 5}}, v)
+
+	// The second Get is served from the cache.
+	again, _, _ := d.Get("files")
+	assert.Equal(t, v, again)
+
+	v, ok, err = d.Get("name")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "test", v)
+
+	v, ok, err = d.Get("x")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, v)
+
+	_, ok, err = d.Get("missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNewLazyDict(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedErr error
+	}{
+		// Positive cases
+		{
+			name: "valid: empty dict",
+			in:   "de",
+		},
+		{
+			name: "valid: nested values",
+			in:   "d1:ad1:bli1ei2eee1:c0:e",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: not a dict",
+			in:          "li1ee",
+			expectedErr: ErrDictInvalid,
+		},
+		{
+			name:        "invalid: int key",
+			in:          "di1ei1ee",
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: int key inside a value",
+			in:          "d1:adi1ei1eee",
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: unterminated",
+			in:          "d1:ai1e",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: broken nested int",
+			in:          "d1:ali1xeee",
+			expectedErr: ErrIntInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewLazyDict([]byte(test.in))
+
+			if test.expectedErr != nil {
+				assert.EqualError(t, err, test.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package bencode
+
+import (
+	"io"
+	"net"
+	"time"
+)
+
+// ReadMessage reads one value from a peer, e.g. a message of the DHT
+// or of a peer wire extension, the way a Decoder reads it. A value marks
+// its own end, so it needs no framing.
+//
+// The peer has idle time to send each part of the value, so a slow
+// but steady peer is not cut off, while a silent one is. conn is read
+// a byte at a time, so nothing past the value is consumed and the next
+// ReadMessage on conn picks up the next value. The read deadline is
+// cleared before returning.
+//
+// A peer that goes silent gives the error of the read that timed out,
+// which satisfies errors.Is(err, os.ErrDeadlineExceeded) and is neither
+// truncated nor malformed to IsTruncated and IsMalformed; the same goes
+// for any other error of conn. A peer that closed the connection between
+// values gives io.EOF, one that closed it inside a value gives an error
+// IsTruncated reports. After any error conn is out of step with the peer
+// and is best closed.
+func ReadMessage(conn net.Conn, idle time.Duration) (interface{}, error) {
+	defer conn.SetReadDeadline(time.Time{})
+
+	r := &idleReader{conn: conn, idle: idle}
+	v, err := NewDecoder(r).Decode()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return v, err
+}
+
+// idleReader reads from conn a byte at a time, moving the read deadline
+// idle time into the future before every read. It keeps the first error
+// of conn other than io.EOF, which the decoder wraps as broken input.
+type idleReader struct {
+	conn net.Conn
+	idle time.Duration
+	err  error
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if len(p) > 1 {
+		p = p[:1]
+	}
+
+	err := r.conn.SetReadDeadline(time.Now().Add(r.idle))
+	n := 0
+	if err == nil {
+		n, err = r.conn.Read(p)
+	}
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+
+	return n, err
+}
@@ -0,0 +1,113 @@
+package bencode
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedValue interface{}
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: dict",
+			in:            "d1:ai1ee",
+			expectedValue: map[string]interface{}{"a": 1},
+		},
+		{
+			name:          "valid: string longer than one read",
+			in:            "10:0123456789",
+			expectedValue: "0123456789",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: not a value",
+			in:          "x",
+			expectedErr: ErrValueInvalid,
+		},
+		{
+			name:        "invalid: huge string length",
+			in:          "999999999999999999:",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: connection closed between messages",
+			in:          "",
+			expectedErr: io.EOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer server.Close()
+			go func() {
+				_, _ = client.Write([]byte(test.in))
+				client.Close()
+			}()
+
+			v, err := ReadMessage(server, time.Second)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
+
+func TestReadMessageStream(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		_, _ = client.Write([]byte("i1el1:ae"))
+		client.Close()
+	}()
+
+	v, err := ReadMessage(server, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	v, err = ReadMessage(server, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a"}, v)
+}
+
+func TestReadMessageTruncated(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		_, _ = client.Write([]byte("d1:ai1e"))
+		client.Close()
+	}()
+
+	_, err := ReadMessage(server, time.Second)
+	assert.True(t, IsTruncated(err))
+	assert.False(t, IsMalformed(err))
+}
+
+func TestReadMessageTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go func() {
+		_, _ = client.Write([]byte("d1:ai1"))
+	}()
+
+	_, err := ReadMessage(server, 50*time.Millisecond)
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.False(t, IsTruncated(err))
+	assert.False(t, IsMalformed(err))
+}
@@ -0,0 +1,37 @@
+package bencode
+
+import "fmt"
+
+// NormalizeIntegers rewrites every integer in data in canonical form,
+// without leading zeros and without a negative zero, and leaves
+// everything else alone: dictionary keys keep their order and strings,
+// length prefixes included, are copied byte for byte.
+//
+// Example:
+// d1:bi007e1:ai-0ee
+// becomes
+// d1:bi7e1:ai0ee
+//
+// data has to hold exactly one value.
+func NormalizeIntegers(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+
+	// Everything between two ints is copied as it is.
+	last := 0
+	w := &walker{data: data, onInt: func(start, end int, canonical []byte) {
+		out = append(out, data[last:start]...)
+		out = append(out, 'i')
+		out = append(out, canonical...)
+		out = append(out, 'e')
+		last = end
+	}}
+	n, err := w.value(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(data) {
+		return nil, fmt.Errorf("%w: %d bytes after the value", ErrTrailingData, len(data)-n)
+	}
+
+	return append(out, data[last:]...), nil
+}
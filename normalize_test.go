@@ -0,0 +1,115 @@
+package bencode
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeIntegers(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedOut string
+		expectedErr error
+	}{
+		// Positive cases
+		{
+			name:        "valid: canonical int is unchanged",
+			in:          "i42e",
+			expectedOut: "i42e",
+		},
+		{
+			name:        "valid: leading zeros are dropped",
+			in:          "i007e",
+			expectedOut: "i7e",
+		},
+		{
+			name:        "valid: negative leading zeros are dropped",
+			in:          "i-007e",
+			expectedOut: "i-7e",
+		},
+		{
+			name:        "valid: zeros become a single zero",
+			in:          "i000e",
+			expectedOut: "i0e",
+		},
+		{
+			name:        "valid: negative zero becomes zero",
+			in:          "i-0e",
+			expectedOut: "i0e",
+		},
+		{
+			name:        "valid: ints beyond int64 are fine",
+			in:          "i0099999999999999999999e",
+			expectedOut: "i99999999999999999999e",
+		},
+		{
+			name:        "valid: key order and strings are preserved",
+			in:          "d1:bi007e1:al02:xxi-0ee3:\x00e\x01e",
+			expectedOut: "d1:bi7e1:al02:xxi0ee3:\x00e\x01e",
+		},
+		{
+			name:        "valid: key without a value is kept",
+			in:          "d1:ae",
+			expectedOut: "d1:ae",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: empty int",
+			in:          "ie",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: not a number",
+			in:          "i1a2e",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: unterminated list",
+			in:          "li1e",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: string runs past the input",
+			in:          "5:ab",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: huge string length",
+			in:          "99999999999999999999999:ab",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: int key",
+			in:          "di1ei1ee",
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: empty input",
+			in:          "",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := NormalizeIntegers([]byte(test.in))
+
+			if test.expectedErr != nil {
+				assert.EqualError(t, err, test.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedOut, string(out))
+			}
+		})
+	}
+}
+
+func TestNormalizeIntegersTrailingData(t *testing.T) {
+	_, err := NormalizeIntegers([]byte("i1ei2e"))
+
+	assert.EqualError(t, err, "trailing data: 3 bytes after the value")
+}
@@ -0,0 +1,64 @@
+package bencode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPieceLayersInvalid is returned when the piece layers
+// of a v2 torrent don't have the expected shape.
+var ErrPieceLayersInvalid error = errors.New("invalid piece layers")
+
+const pieceLayersKey = "piece layers"
+
+// PieceLayers extracts the piece layers of a decoded v2 torrent.
+//
+// In the torrent they are a dictionary mapping the 32-byte merkle root
+// of every file to the concatenated 32-byte hashes of its piece layer:
+// d12:piece layersd32:<root>64:<hash><hash>ee
+//
+// A torrent without piece layers gives an empty map.
+// Anything else that isn't a dictionary of 32-byte keys to non-empty
+// strings whose length is a multiple of 32 is an ErrPieceLayersInvalid.
+// The layers may be strings or []byte, see Decoder.Bytes.
+func PieceLayers(torrent map[string]interface{}) (map[[32]byte][][32]byte, error) {
+	layers := make(map[[32]byte][][32]byte)
+
+	v, ok := torrent[pieceLayersKey]
+	if !ok {
+		return layers, nil
+	}
+	d, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: expected dict, got %T", ErrPieceLayersInvalid, v)
+	}
+
+	for k, v := range d {
+		if len(k) != 32 {
+			return nil, fmt.Errorf("%w: root %x is %d bytes long", ErrPieceLayersInvalid, k, len(k))
+		}
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		default:
+			return nil, fmt.Errorf("%w: layer of %x: expected string, got %T", ErrPieceLayersInvalid, k, v)
+		}
+		if len(s) == 0 || len(s)%32 != 0 {
+			return nil, fmt.Errorf("%w: layer of %x is %d bytes long", ErrPieceLayersInvalid, k, len(s))
+		}
+
+		var root [32]byte
+		copy(root[:], k)
+
+		hashes := make([][32]byte, len(s)/32)
+		for i := range hashes {
+			copy(hashes[i][:], s[i*32:])
+		}
+		layers[root] = hashes
+	}
+
+	return layers, nil
+}
@@ -0,0 +1,105 @@
+package bencode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPieceLayers(t *testing.T) {
+	root := strings.Repeat("r", 32)
+	h1 := strings.Repeat("1", 32)
+	h2 := strings.Repeat("2", 32)
+
+	var rootKey, hash1, hash2 [32]byte
+	copy(rootKey[:], root)
+	copy(hash1[:], h1)
+	copy(hash2[:], h2)
+
+	tests := []struct {
+		name           string
+		torrent        map[string]interface{}
+		expectedLayers map[[32]byte][][32]byte
+		expectedErr    bool
+	}{
+		// Positive cases
+		{
+			name:           "valid: no piece layers",
+			torrent:        map[string]interface{}{},
+			expectedLayers: map[[32]byte][][32]byte{},
+		},
+		{
+			name: "valid: one file with two pieces",
+			torrent: map[string]interface{}{
+				"piece layers": map[string]interface{}{root: h1 + h2},
+			},
+			expectedLayers: map[[32]byte][][32]byte{rootKey: {hash1, hash2}},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: not a dict",
+			torrent:     map[string]interface{}{"piece layers": "x"},
+			expectedErr: true,
+		},
+		{
+			name: "invalid: short root",
+			torrent: map[string]interface{}{
+				"piece layers": map[string]interface{}{"abc": h1},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid: layer isn't a multiple of 32",
+			torrent: map[string]interface{}{
+				"piece layers": map[string]interface{}{root: h1 + "x"},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid: empty layer",
+			torrent: map[string]interface{}{
+				"piece layers": map[string]interface{}{root: ""},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid: layer isn't a string",
+			torrent: map[string]interface{}{
+				"piece layers": map[string]interface{}{root: 1},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			layers, err := PieceLayers(test.torrent)
+
+			if test.expectedErr {
+				assert.ErrorIs(t, err, ErrPieceLayersInvalid)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedLayers, layers)
+			}
+		})
+	}
+}
+
+func TestPieceLayersBytes(t *testing.T) {
+	root := strings.Repeat("r", 32)
+	h := strings.Repeat("1", 32)
+	d := NewDecoder(strings.NewReader("d12:piece layersd32:" + root + "32:" + h + "ee"))
+	d.Bytes = true
+	v, err := d.Decode()
+	assert.NoError(t, err)
+
+	var rootKey, hash [32]byte
+	copy(rootKey[:], root)
+	copy(hash[:], h)
+
+	layers, err := PieceLayers(v.(map[string]interface{}))
+	assert.NoError(t, err)
+	assert.Equal(t, map[[32]byte][][32]byte{rootKey: {hash}}, layers)
+}
@@ -0,0 +1,61 @@
+package bencode
+
+import "bufio"
+
+// InfoCallbacks are called by ReadTorrentProgressive as soon as
+// the matching field of the info dictionary has been read.
+// Any of them may be nil.
+type InfoCallbacks struct {
+	// Name gets the name of the torrent.
+	Name func(name string)
+	// PieceLength gets the number of bytes in each piece.
+	PieceLength func(n int)
+	// Length gets the file size of a single-file torrent.
+	Length func(n int)
+	// Files gets the number of files of a multi-file torrent.
+	Files func(count int)
+}
+
+// ReadTorrentProgressive reads a torrent dictionary like ReadDictionary
+// does, but reports the interesting fields of its info dictionary
+// through cb while the rest is still being read.
+//
+// Keys are sorted, so files, length, name and piece length
+// all come before the pieces, which are by far the biggest part
+// of a torrent. A UI can show them without waiting for the hashes.
+//
+// A field of an unexpected type is not reported,
+// it's only kept in the returned dictionary.
+func ReadTorrentProgressive(r *bufio.Reader, cb InfoCallbacks) (map[string]interface{}, error) {
+	d := &Decoder{r: r}
+	d.onEntry = func(path []string, v interface{}) {
+		// Only the entries of a dictionary that's right under info,
+		// with no list in between.
+		if len(path) == 2 && d.depth == 2 && path[0] == "info" {
+			cb.report(path[1], v)
+		}
+	}
+
+	return d.readDictionary()
+}
+
+// report passes the value of the info field k to its callback.
+func (cb InfoCallbacks) report(k string, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		if k == "name" && cb.Name != nil {
+			cb.Name(v)
+		}
+	case int:
+		if k == "piece length" && cb.PieceLength != nil {
+			cb.PieceLength(v)
+		}
+		if k == "length" && cb.Length != nil {
+			cb.Length(v)
+		}
+	case []interface{}:
+		if k == "files" && cb.Files != nil {
+			cb.Files(len(v))
+		}
+	}
+}
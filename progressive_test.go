@@ -0,0 +1,107 @@
+package bencode
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTorrentProgressive(t *testing.T) {
+	in := "d8:announce3:url4:infod5:filesld6:lengthi1eed6:lengthi2eee" +
+		"4:name4:test12:piece lengthi16384e6:pieces2:\x00\x01ee"
+
+	var events []string
+	cb := InfoCallbacks{
+		Name:        func(name string) { events = append(events, "name "+name) },
+		PieceLength: func(n int) { events = append(events, "piece length") },
+		Length:      func(n int) { events = append(events, "length") },
+		Files:       func(count int) { events = append(events, "files") },
+	}
+
+	d, err := ReadTorrentProgressive(bufio.NewReader(strings.NewReader(in)), cb)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"files", "name test", "piece length"}, events)
+
+	expected, err := ReadDictionary(bufio.NewReader(strings.NewReader(in)))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, d)
+}
+
+func TestReadTorrentProgressiveFieldsBeforeError(t *testing.T) {
+	// The input is cut in the middle of the pieces,
+	// but the fields before them have been reported by then.
+	in := "d4:infod6:lengthi7e4:name4:test6:pieces20:\x00"
+
+	var (
+		name   string
+		length int
+	)
+	cb := InfoCallbacks{
+		Name:   func(n string) { name = n },
+		Length: func(n int) { length = n },
+	}
+
+	_, err := ReadTorrentProgressive(bufio.NewReader(strings.NewReader(in)), cb)
+	assert.Error(t, err)
+	assert.Equal(t, "test", name)
+	assert.Equal(t, 7, length)
+}
+
+func TestReadTorrentProgressiveOnlyInfoFields(t *testing.T) {
+	// Neither a name nested deeper in info, nor one in a list
+	// that takes the place of info, is the name of the torrent.
+	in := "d4:infod5:filesld4:name1:aee6:nestedd4:name1:bee" +
+		"5:otherd4:name1:cee" +
+		"e"
+
+	var names []string
+	cb := InfoCallbacks{Name: func(n string) { names = append(names, n) }}
+
+	_, err := ReadTorrentProgressive(bufio.NewReader(strings.NewReader(in)), cb)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	_, err = ReadTorrentProgressive(bufio.NewReader(strings.NewReader("d4:infold4:name1:aeee")), cb)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestReadTorrentProgressiveInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedErr error
+	}{
+		{
+			name:        "invalid: not a dict",
+			in:          "le",
+			expectedErr: ErrDictInvalid,
+		},
+		{
+			name:        "invalid: unterminated info",
+			in:          "d4:infod",
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "invalid: int key in info",
+			in:          "d4:infodi1ei1eee",
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: nested too deep",
+			in:          "d4:info" + strings.Repeat("l", DefaultMaxDepth),
+			expectedErr: ErrMaxDepthExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadTorrentProgressive(bufio.NewReader(strings.NewReader(test.in)), InfoCallbacks{})
+
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
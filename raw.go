@@ -0,0 +1,57 @@
+package bencode
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// RawMessage is a value in its bencoded form, left undecoded.
+//
+// Unmarshal stores the exact bytes of a value in a RawMessage,
+// and Marshal writes them back unchanged. That's what computing
+// the info hash of a torrent takes:
+//
+//	var t struct {
+//		Info bencode.RawMessage `bencode:"info"`
+//	}
+//	err := bencode.Unmarshal(data, &t)
+//	hash := sha1.Sum(t.Info)
+type RawMessage []byte
+
+// MarshalBencode returns m, once it's made sure m holds exactly one value.
+func (m RawMessage) MarshalBencode() ([]byte, error) {
+	n, err := valueLength(m, 0)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(m) {
+		return nil, fmt.Errorf("%w: %d bytes after the value", ErrTrailingData, len(m)-n)
+	}
+
+	return m, nil
+}
+
+// UnmarshalBencode sets *m to a copy of data.
+func (m *RawMessage) UnmarshalBencode(data []byte) error {
+	*m = append((*m)[:0], data...)
+
+	return nil
+}
+
+// ReadRaw reads a value of whatever type comes next, the way ReadValue
+// does, but returns its bytes exactly as they are in r instead of
+// decoding it.
+//
+// Example:
+// d4:infod4:name4:testee
+// gives d4:infod4:name4:testee, also when its keys are not sorted
+// or its ints not in canonical form, which re-encoding would change.
+func ReadRaw(r *bufio.Reader) ([]byte, error) {
+	// The scanner reads r itself, so nothing past the value is consumed.
+	s := &Scanner{r: r, record: true}
+	if _, _, err := s.Skip(); err != nil {
+		return nil, err
+	}
+
+	return s.raw, nil
+}
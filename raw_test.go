@@ -0,0 +1,188 @@
+package bencode
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalRawMessage(t *testing.T) {
+	// The info dict is not in canonical form on purpose,
+	// its bytes have to be kept exactly as they are.
+	data := []byte("d8:announce3:url4:infod4:name4:test6:lengthi007eee")
+
+	var v struct {
+		Announce string     `bencode:"announce"`
+		Info     RawMessage `bencode:"info"`
+	}
+	assert.NoError(t, Unmarshal(data, &v))
+	assert.Equal(t, "url", v.Announce)
+	assert.Equal(t, RawMessage("d4:name4:test6:lengthi007ee"), v.Info)
+	assert.Equal(t, sha1.Sum([]byte("d4:name4:test6:lengthi007ee")), sha1.Sum(v.Info))
+
+	// The message doesn't share memory with data.
+	data[25] = 'X'
+	assert.Equal(t, RawMessage("d4:name4:test6:lengthi007ee"), v.Info)
+
+	var m RawMessage
+	assert.NoError(t, Unmarshal([]byte("li1ee"), &m))
+	assert.Equal(t, RawMessage("li1ee"), m)
+}
+
+func TestMarshalRawMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          interface{}
+		expected    string
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:     "valid: written as is",
+			in:       RawMessage("d1:bi1e1:ai007ee"),
+			expected: "d1:bi1e1:ai007ee",
+		},
+		{
+			name: "valid: struct field",
+			in: struct {
+				Info RawMessage `bencode:"info"`
+			}{Info: RawMessage("d4:name4:teste")},
+			expected: "d4:infod4:name4:testee",
+		},
+		{
+			name:     "valid: list element",
+			in:       []interface{}{RawMessage("i1e"), 2},
+			expected: "li1ei2ee",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: not bencode",
+			in:          RawMessage("x"),
+			expectedErr: "marshal bencode.RawMessage: invalid string",
+		},
+		{
+			name:        "invalid: more than one value",
+			in:          RawMessage("i1ei2e"),
+			expectedErr: "marshal bencode.RawMessage: trailing data: 3 bytes after the value",
+		},
+		{
+			name:        "invalid: empty",
+			in:          RawMessage{},
+			expectedErr: "marshal bencode.RawMessage: unexpected EOF",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := Marshal(test.in)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, string(b))
+			}
+		})
+	}
+}
+
+func TestReadRaw(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expectedRaw string
+		expectedErr error
+	}{
+		// Positive cases
+		{
+			name:        "valid: int",
+			in:          "i-42e",
+			expectedRaw: "i-42e",
+		},
+		{
+			name:        "valid: string with a padded length",
+			in:          "04:spam",
+			expectedRaw: "04:spam",
+		},
+		{
+			name:        "valid: list",
+			in:          "l4:spami1ee",
+			expectedRaw: "l4:spami1ee",
+		},
+		{
+			name:        "valid: dict is kept as is",
+			in:          "d1:bi007e1:ad1:xleee",
+			expectedRaw: "d1:bi007e1:ad1:xleee",
+		},
+		{
+			name:        "valid: key right before the end",
+			in:          "d1:ae",
+			expectedRaw: "d1:ae",
+		},
+		{
+			name:        "valid: only the first value is read",
+			in:          "d1:ai1eei2e",
+			expectedRaw: "d1:ai1ee",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: broken int",
+			in:          "iae",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: string shorter than its length",
+			in:          "5:ab",
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: int as a key",
+			in:          "di1ei2ee",
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: unexpected byte",
+			in:          "x",
+			expectedErr: ErrValueInvalid,
+		},
+		{
+			name:        "invalid: unterminated list",
+			in:          "li1e",
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: too deep",
+			in:          strings.Repeat("l", DefaultMaxDepth+1),
+			expectedErr: ErrMaxDepthExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			raw, err := ReadRaw(r)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedRaw, string(raw))
+			}
+		})
+	}
+}
+
+func TestReadRawAcrossShortReads(t *testing.T) {
+	in := "d4:infod4:name4:test6:pieces20:aaaaaaaaaaaaaaaaaaaaee"
+	raw, err := ReadRaw(bufio.NewReader(iotest.OneByteReader(strings.NewReader(in))))
+
+	assert.NoError(t, err)
+	assert.Equal(t, in, string(raw))
+}
@@ -0,0 +1,47 @@
+package bencode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrOffsetInvalid is returned by DecodeAt for a negative offset.
+var ErrOffsetInvalid error = errors.New("invalid offset")
+
+// countingReader counts bytes handed out by the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+// DecodeAt decodes a single value starting at offset in r.
+//
+// It returns the value and the number of bytes it took in the input,
+// so the next value (if any) starts at offset+n. Nothing before offset
+// is read, which makes it handy for jumping straight to a value whose
+// position is known in advance, e.g. the info dictionary of a big torrent.
+// A negative offset is an ErrOffsetInvalid.
+func DecodeAt(r io.ReaderAt, offset int64) (interface{}, int, error) {
+	if offset < 0 {
+		return nil, 0, fmt.Errorf("%w: %d", ErrOffsetInvalid, offset)
+	}
+
+	cr := &countingReader{r: io.NewSectionReader(r, offset, math.MaxInt64-offset)}
+	br := bufio.NewReader(cr)
+
+	v, err := ReadValue(br)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return v, cr.n - br.Buffered(), nil
+}
@@ -0,0 +1,78 @@
+package bencode
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeAt(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		offset        int64
+		expectedValue interface{}
+		expectedN     int
+		expectedErr   error
+	}{
+		// Positive cases
+		{
+			name:          "valid: int at the start",
+			in:            "i42e",
+			expectedValue: 42,
+			expectedN:     4,
+		},
+		{
+			name:          "valid: string in the middle",
+			in:            "i1e4:spami2e",
+			offset:        3,
+			expectedValue: "spam",
+			expectedN:     6,
+		},
+		{
+			name:   "valid: dict after a header",
+			in:     "junkd1:ali1ei2eee",
+			offset: 4,
+			expectedValue: map[string]interface{}{
+				"a": []interface{}{1, 2},
+			},
+			expectedN: 13,
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: offset at the end of input",
+			in:          "i1e",
+			offset:      3,
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "invalid: broken value at offset",
+			in:          "i1eix",
+			offset:      3,
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:        "invalid: negative offset",
+			in:          "i1e",
+			offset:      -1,
+			expectedErr: ErrOffsetInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, n, err := DecodeAt(strings.NewReader(test.in), test.offset)
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+				assert.Equal(t, test.expectedN, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package bencode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// TokenKind tells what a Token is.
+type TokenKind int
+
+const (
+	// IntToken is an int, its value is in Token.Int.
+	IntToken TokenKind = iota + 1
+	// StringToken is a string or a dictionary key,
+	// its bytes are in Token.Bytes.
+	StringToken
+	// ListStart is the l a list starts with.
+	ListStart
+	// ListEnd is the e a list ends with.
+	ListEnd
+	// DictStart is the d a dictionary starts with.
+	DictStart
+	// DictEnd is the e a dictionary ends with.
+	DictEnd
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case IntToken:
+		return "int"
+	case StringToken:
+		return "string"
+	case ListStart:
+		return "list start"
+	case ListEnd:
+		return "list end"
+	case DictStart:
+		return "dict start"
+	case DictEnd:
+		return "dict end"
+	default:
+		return fmt.Sprintf("TokenKind(%d)", int(k))
+	}
+}
+
+// Token is a piece of the input as the Scanner sees it.
+type Token struct {
+	Kind TokenKind
+	// Int is the value of an IntToken.
+	Int int64
+	// Bytes are the bytes of a StringToken.
+	Bytes []byte
+	// Start is the offset of the first byte of the token in the input,
+	// End the offset right after its last one, so for a whole list
+	// or dictionary the raw bytes are from the Start of its start token
+	// to the End of its end token.
+	Start, End int64
+}
+
+// Scanner reads bencoded values a token at a time,
+// without building them in memory.
+//
+// Example:
+// d1:ali1eee
+// is scanned as DictStart, StringToken "a", ListStart,
+// IntToken 1, ListEnd, DictEnd.
+type Scanner struct {
+	r source
+	// n is the number of bytes of input consumed so far.
+	n int64
+	// raw, if record is set, gets a copy of every byte consumed,
+	// which is how ReadRaw keeps a value as it is.
+	raw    []byte
+	record bool
+	// discard, set while skipping, drops string bodies unread
+	// unless they're recorded.
+	discard bool
+	// open holds the lists and dictionaries the scanner is inside of.
+	open []scanFrame
+}
+
+type scanFrame struct {
+	dict bool
+	// key is set when a dictionary expects a key next.
+	key bool
+}
+
+// NewScanner returns a new scanner that reads from r.
+//
+// The scanner buffers its input and may read past the values it scans.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{r: bufio.NewReader(r)}
+}
+
+// Next returns the next token.
+//
+// Once the stream is over between two values Next returns io.EOF,
+// a stream which ends inside a value is an io.ErrUnexpectedEOF.
+// Any other error is a *SyntaxError.
+func (s *Scanner) Next() (Token, error) {
+	start := s.n
+	next, err := s.r.Peek(1)
+	if err == io.EOF && len(s.open) != 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return Token{}, err
+	}
+
+	t := Token{Start: start}
+	switch b := next[0]; {
+	case b == 'e' && len(s.open) != 0:
+		s.readByte()
+		t.Kind = ListEnd
+		if s.open[len(s.open)-1].dict {
+			t.Kind = DictEnd
+		}
+		s.open = s.open[:len(s.open)-1]
+		s.valueDone()
+	case len(s.open) != 0 && s.open[len(s.open)-1].key && !isDigit(b):
+		err = fmt.Errorf("%w: unexpected %q", ErrStringInvalid, b)
+	case b == 'i':
+		t.Kind = IntToken
+		var body []byte
+		// Recording for ReadRaw needs no values, so it takes ints of any size.
+		if body, err = s.readInt(); err == nil && !s.record {
+			t.Int, err = parseInt(body, 64)
+		}
+		s.valueDone()
+	case isDigit(b):
+		t.Kind = StringToken
+		t.Bytes, err = s.readString()
+		s.valueDone()
+	case b == 'l' || b == 'd':
+		if len(s.open) >= DefaultMaxDepth {
+			err = ErrMaxDepthExceeded
+			break
+		}
+		s.readByte()
+		t.Kind = ListStart
+		if b == 'd' {
+			t.Kind = DictStart
+		}
+		s.open = append(s.open, scanFrame{dict: b == 'd', key: b == 'd'})
+	default:
+		err = fmt.Errorf("%w: unexpected %q", ErrValueInvalid, b)
+	}
+	if err != nil {
+		return Token{}, &SyntaxError{Offset: start, Err: err}
+	}
+	t.End = s.n
+
+	return t, nil
+}
+
+// Skip skips the next value, with everything in it if it's a list
+// or a dictionary, and returns its byte range the way a Token does.
+// Strings are skipped over without being kept in memory.
+//
+// Example:
+// to skip the value of a key, call Skip right after Next returns the key.
+func (s *Scanner) Skip() (start, end int64, err error) {
+	depth := len(s.open)
+	if next, err := s.r.Peek(1); err == nil && next[0] == 'e' && depth != 0 {
+		return 0, 0, &SyntaxError{Offset: s.n, Err: fmt.Errorf("%w: no value to skip", ErrValueInvalid)}
+	}
+
+	s.discard = true
+	defer func() { s.discard = false }()
+
+	t, err := s.Next()
+	if err != nil {
+		return 0, 0, err
+	}
+	start = t.Start
+	for len(s.open) > depth {
+		if t, err = s.Next(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return start, t.End, nil
+}
+
+// valueDone moves a dictionary the scanner is in
+// between expecting a key and a value.
+func (s *Scanner) valueDone() {
+	if n := len(s.open); n != 0 && s.open[n-1].dict {
+		s.open[n-1].key = !s.open[n-1].key
+	}
+}
+
+// readByte consumes the byte the scanner has peeked at.
+func (s *Scanner) readByte() {
+	b, _ := s.r.ReadByte()
+	s.consumed(b)
+}
+
+// readInt reads an int and returns its <integer> part,
+// checked the way the slice walker does it.
+func (s *Scanner) readInt() ([]byte, error) {
+	body, err := readIntBody(s.r)
+	if err != nil {
+		return nil, err
+	}
+	s.consumed('i')
+	s.consumed(body...)
+	s.consumed('e')
+
+	if _, err := canonicalInt(body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// readString reads a string like ReadBytes does.
+func (s *Scanner) readString() ([]byte, error) {
+	l, err := s.r.ReadSlice(stringSeparator)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStringInvalid, err)
+	}
+	s.consumed(l...)
+	length, err := parseLength(l[:len(l)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	if s.discard && !s.record {
+		n, err := s.r.Discard(length)
+		s.n += int64(n)
+		if err != nil {
+			return nil, bodyError(err)
+		}
+		return nil, nil
+	}
+	b, err := readBody(s.r, length)
+	if err != nil {
+		return nil, err
+	}
+	s.consumed(b...)
+
+	return b, nil
+}
+
+func (s *Scanner) consumed(b ...byte) {
+	s.n += int64(len(b))
+	if s.record {
+		s.raw = append(s.raw, b...)
+	}
+}
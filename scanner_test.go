@@ -0,0 +1,143 @@
+package bencode
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanner(t *testing.T) {
+	tests := []struct {
+		name           string
+		in             string
+		expectedTokens []Token
+		expectedErr    error
+	}{
+		// Positive cases
+		{
+			name: "valid: dict with a list",
+			in:   "d1:ali1e2:xyee",
+			expectedTokens: []Token{
+				{Kind: DictStart, Start: 0, End: 1},
+				{Kind: StringToken, Bytes: []byte("a"), Start: 1, End: 4},
+				{Kind: ListStart, Start: 4, End: 5},
+				{Kind: IntToken, Int: 1, Start: 5, End: 8},
+				{Kind: StringToken, Bytes: []byte("xy"), Start: 8, End: 12},
+				{Kind: ListEnd, Start: 12, End: 13},
+				{Kind: DictEnd, Start: 13, End: 14},
+			},
+		},
+		{
+			name: "valid: stream of values",
+			in:   "i-1e0:",
+			expectedTokens: []Token{
+				{Kind: IntToken, Int: -1, Start: 0, End: 4},
+				{Kind: StringToken, Bytes: []byte{}, Start: 4, End: 6},
+			},
+		},
+		{
+			name: "valid: key right before the end",
+			in:   "d1:ae",
+			expectedTokens: []Token{
+				{Kind: DictStart, Start: 0, End: 1},
+				{Kind: StringToken, Bytes: []byte("a"), Start: 1, End: 4},
+				{Kind: DictEnd, Start: 4, End: 5},
+			},
+		},
+
+		// Negative cases
+		{
+			name: "invalid: int as a key",
+			in:   "di1ei2ee",
+			expectedTokens: []Token{
+				{Kind: DictStart, Start: 0, End: 1},
+			},
+			expectedErr: ErrStringInvalid,
+		},
+		{
+			name:        "invalid: e outside of a list",
+			in:          "e",
+			expectedErr: ErrValueInvalid,
+		},
+		{
+			name: "invalid: ends inside a list",
+			in:   "li1e",
+			expectedTokens: []Token{
+				{Kind: ListStart, Start: 0, End: 1},
+				{Kind: IntToken, Int: 1, Start: 1, End: 4},
+			},
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "invalid: broken int",
+			in:          "iae",
+			expectedErr: ErrIntInvalid,
+		},
+		{
+			name:           "invalid: too deep",
+			in:             strings.Repeat("l", DefaultMaxDepth+1),
+			expectedTokens: nil,
+			expectedErr:    ErrMaxDepthExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewScanner(iotest.OneByteReader(strings.NewReader(test.in)))
+
+			var tokens []Token
+			var err error
+			for {
+				var tok Token
+				if tok, err = s.Next(); err != nil {
+					break
+				}
+				tokens = append(tokens, tok)
+			}
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.ErrorIs(t, err, io.EOF)
+			}
+			if test.expectedTokens != nil {
+				assert.Equal(t, test.expectedTokens, tokens)
+			}
+		})
+	}
+}
+
+func TestScannerSkip(t *testing.T) {
+	in := "d5:filesld6:lengthi1eee4:name4:teste"
+	s := NewScanner(strings.NewReader(in))
+
+	tok, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, DictStart, tok.Kind)
+
+	tok, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "files", string(tok.Bytes))
+
+	start, end, err := s.Skip()
+	assert.NoError(t, err)
+	assert.Equal(t, "ld6:lengthi1eee", in[start:end])
+
+	tok, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "name", string(tok.Bytes))
+
+	start, end, err = s.Skip()
+	assert.NoError(t, err)
+	assert.Equal(t, "4:test", in[start:end])
+
+	_, _, err = s.Skip()
+	assert.ErrorIs(t, err, ErrValueInvalid)
+
+	tok, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, DictEnd, tok.Kind)
+}
@@ -0,0 +1,151 @@
+package bencode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrSchemaMismatch is returned when a decoded value doesn't fit its Schema.
+var ErrSchemaMismatch error = errors.New("schema mismatch")
+
+// Kind is the type a Schema expects a value to have.
+type Kind int
+
+const (
+	// KindAny accepts any value as is.
+	KindAny Kind = iota
+	// KindInt expects an int.
+	KindInt
+	// KindString expects a string.
+	KindString
+	// KindBytes expects a string and converts it to a []byte,
+	// which suits binary values like pieces.
+	KindBytes
+	// KindList expects a list.
+	KindList
+	// KindDict expects a dictionary.
+	KindDict
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindInt:
+		return "int"
+	case KindString:
+		return "string"
+	case KindBytes:
+		return "bytes"
+	case KindList:
+		return "list"
+	case KindDict:
+		return "dict"
+	default:
+		return "any"
+	}
+}
+
+// Schema describes the expected shape of a decoded value.
+//
+// Example, the minimal single-file torrent:
+//
+//	&Schema{Kind: KindDict, Required: []string{"info"}, Fields: map[string]*Schema{
+//		"announce": {Kind: KindString},
+//		"info": {Kind: KindDict, Fields: map[string]*Schema{
+//			"length": {Kind: KindInt},
+//			"pieces": {Kind: KindBytes},
+//		}},
+//	}}
+type Schema struct {
+	Kind Kind
+	// Elem is the schema of every list element, nil accepts anything.
+	Elem *Schema
+	// Fields holds the schemas of known dictionary keys.
+	// Keys without a schema are kept as they are.
+	Fields map[string]*Schema
+	// Required lists dictionary keys which must be present.
+	Required []string
+}
+
+// DecodeWithSchema reads a single value from r and checks it against s,
+// converting values where the schema asks for it.
+//
+// A mismatch is reported as ErrSchemaMismatch along with
+// the path to the offending value, e.g. info.files[0].length.
+func DecodeWithSchema(r io.Reader, s *Schema) (interface{}, error) {
+	v, err := ReadValue(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return applySchema(v, s, "")
+}
+
+func applySchema(v interface{}, s *Schema, path string) (interface{}, error) {
+	if s == nil {
+		return v, nil
+	}
+
+	mismatch := func() error {
+		if path == "" {
+			return fmt.Errorf("expected %s, got %T: %w", s.Kind, v, ErrSchemaMismatch)
+		}
+		return fmt.Errorf("%s: expected %s, got %T: %w", path, s.Kind, v, ErrSchemaMismatch)
+	}
+
+	switch s.Kind {
+	case KindInt:
+		if _, ok := v.(int); !ok {
+			return nil, mismatch()
+		}
+	case KindString:
+		if _, ok := v.(string); !ok {
+			return nil, mismatch()
+		}
+	case KindBytes:
+		str, ok := v.(string)
+		if !ok {
+			return nil, mismatch()
+		}
+		return []byte(str), nil
+	case KindList:
+		l, ok := v.([]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		for i, e := range l {
+			e, err := applySchema(e, s.Elem, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			l[i] = e
+		}
+	case KindDict:
+		d, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		for _, k := range s.Required {
+			if _, ok := d[k]; !ok {
+				return nil, fmt.Errorf("%s: missing: %w", joinPath(path, k), ErrSchemaMismatch)
+			}
+		}
+		for k, e := range d {
+			e, err := applySchema(e, s.Fields[k], joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			d[k] = e
+		}
+	}
+
+	return v, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,103 @@
+package bencode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeWithSchema(t *testing.T) {
+	torrent := &Schema{
+		Kind:     KindDict,
+		Required: []string{"info"},
+		Fields: map[string]*Schema{
+			"announce": {Kind: KindString},
+			"info": {Kind: KindDict, Fields: map[string]*Schema{
+				"files": {Kind: KindList, Elem: &Schema{Kind: KindDict, Fields: map[string]*Schema{
+					"length": {Kind: KindInt},
+				}}},
+				"pieces": {Kind: KindBytes},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		in            string
+		schema        *Schema
+		expectedValue interface{}
+		expectedErr   string
+	}{
+		// Positive cases
+		{
+			name:          "valid: nil schema accepts anything",
+			in:            "i1e",
+			expectedValue: 1,
+		},
+		{
+			name:   "valid: torrent, pieces become bytes, unknown keys are kept",
+			in:     "d8:announce3:url4:infod5:filesld6:lengthi5eee6:pieces2:\x00\x01e1:xi1ee",
+			schema: torrent,
+			expectedValue: map[string]interface{}{
+				"announce": "url",
+				"info": map[string]interface{}{
+					"files":  []interface{}{map[string]interface{}{"length": 5}},
+					"pieces": []byte{0, 1},
+				},
+				"x": 1,
+			},
+		},
+		{
+			name:          "valid: list of strings",
+			in:            "l1:a1:be",
+			schema:        &Schema{Kind: KindList, Elem: &Schema{Kind: KindString}},
+			expectedValue: []interface{}{"a", "b"},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: not a list",
+			in:          "i1e",
+			schema:      &Schema{Kind: KindList},
+			expectedErr: "expected list, got int: schema mismatch",
+		},
+		{
+			name:        "invalid: required key is missing",
+			in:          "d8:announce3:urle",
+			schema:      torrent,
+			expectedErr: "info: missing: schema mismatch",
+		},
+		{
+			name:        "invalid: nested value has a wrong type",
+			in:          "d4:infod5:filesld6:length1:5eeee",
+			schema:      torrent,
+			expectedErr: "info.files[0].length: expected int, got string: schema mismatch",
+		},
+		{
+			name:        "invalid: malformed input",
+			in:          "l1:a",
+			schema:      &Schema{Kind: KindList},
+			expectedErr: "EOF",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := DecodeWithSchema(strings.NewReader(test.in), test.schema)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, v)
+			}
+		})
+	}
+}
+
+func TestDecodeWithSchemaErrorIs(t *testing.T) {
+	_, err := DecodeWithSchema(strings.NewReader("i1e"), &Schema{Kind: KindString})
+
+	assert.ErrorIs(t, err, ErrSchemaMismatch)
+}
@@ -0,0 +1,203 @@
+package bencode
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrPieceCountMismatch is returned when the pieces of a torrent
+// don't cover its files.
+var ErrPieceCountMismatch error = errors.New("piece count mismatch")
+
+// pieceHashSize is the size of a SHA-1 piece hash.
+const pieceHashSize = 20
+
+// ValidatePieceCount checks that a decoded torrent has exactly
+// as many piece hashes as it takes to cover its files:
+// len(pieces)/20 == ceil(total length / piece length)
+//
+// The total length is the length of a single-file torrent,
+// or the sum of the lengths of all the files otherwise. The pieces
+// may be a string or a []byte, see Decoder.Bytes.
+func ValidatePieceCount(torrent map[string]interface{}) error {
+	info, ok := torrent["info"].(map[string]interface{})
+	if !ok {
+		return errors.New("info: missing or not a dict")
+	}
+
+	pieceLength, ok := info["piece length"].(int)
+	if !ok || pieceLength <= 0 {
+		return errors.New("piece length: missing or not a positive int")
+	}
+	var size int
+	switch pieces := info["pieces"].(type) {
+	case string:
+		size = len(pieces)
+	case []byte:
+		size = len(pieces)
+	default:
+		return errors.New("pieces: missing or not a string")
+	}
+	if size%pieceHashSize != 0 {
+		return fmt.Errorf("pieces: %d bytes is not a whole number of hashes", size)
+	}
+
+	total, err := totalLength(info)
+	if err != nil {
+		return err
+	}
+
+	expected := total / pieceLength
+	if total%pieceLength != 0 {
+		expected++
+	}
+	if got := size / pieceHashSize; got != expected {
+		return fmt.Errorf("%w: %d bytes in pieces of %d need %d hashes, got %d",
+			ErrPieceCountMismatch, total, pieceLength, expected, got)
+	}
+
+	return nil
+}
+
+// totalLength sums up the sizes of the files of a torrent.
+func totalLength(info map[string]interface{}) (int, error) {
+	if length, ok := info["length"]; ok {
+		l, ok := length.(int)
+		if !ok || l < 0 {
+			return 0, errors.New("length: not a non-negative int")
+		}
+		return l, nil
+	}
+
+	files, ok := info["files"].([]interface{})
+	if !ok {
+		return 0, errors.New("neither length nor files are present")
+	}
+
+	total := 0
+	for i, f := range files {
+		f, ok := f.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("files[%d]: not a dict", i)
+		}
+		l, ok := f["length"].(int)
+		if !ok || l < 0 {
+			return 0, fmt.Errorf("files[%d].length: missing or not a non-negative int", i)
+		}
+		if total > math.MaxInt-l {
+			return 0, fmt.Errorf("files[%d].length: total length overflows int", i)
+		}
+		total += l
+	}
+
+	return total, nil
+}
+
+// WebSeeds returns the web seeds of a decoded torrent, its url-list
+// of BEP 19. The url-list may be a single string or a list of them,
+// both are given as a []string. It's empty when the torrent has
+// no url-list, or one that's an empty string, as some clients write.
+//
+// Strings may be string or []byte, see Decoder.Bytes.
+// A url-list of any other type is an error.
+func WebSeeds(torrent map[string]interface{}) ([]string, error) {
+	switch v := torrent["url-list"].(type) {
+	case nil:
+		return []string{}, nil
+	case string, []byte:
+		if s := toString(v); s != "" {
+			return []string{s}, nil
+		}
+		return []string{}, nil
+	case []interface{}:
+		seeds := make([]string, 0, len(v))
+		for i, e := range v {
+			switch e.(type) {
+			case string, []byte:
+				seeds = append(seeds, toString(e))
+			default:
+				return nil, fmt.Errorf("url-list[%d]: expected string, got %T", i, e)
+			}
+		}
+		return seeds, nil
+	default:
+		return nil, fmt.Errorf("url-list: expected string or list, got %T", v)
+	}
+}
+
+// toString returns a string value, which is a string or a []byte.
+func toString(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+
+	return v.(string)
+}
+
+// lastCreationDate is the last second of the year 9999. A creation date
+// after it is most likely in milliseconds instead of seconds.
+const lastCreationDate = 253402300799
+
+// CreatedAt returns the creation date of a decoded torrent in UTC,
+// and whether it has one. A creation date which is not an int, or not
+// a time between the Unix epoch and the end of the year 9999,
+// is as good as none.
+func CreatedAt(torrent map[string]interface{}) (time.Time, bool) {
+	date, ok := torrent["creation date"].(int)
+	if !ok || date <= 0 || int64(date) > lastCreationDate {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(date), 0).UTC(), true
+}
+
+// DHTNode is a node of the DHT a trackerless torrent bootstraps from.
+type DHTNode struct {
+	Host string
+	Port int
+}
+
+// DHTNodes returns the nodes of a decoded trackerless torrent,
+// its list of [host, port] pairs like
+// l l 9:127.0.0.1 i6881e e l 11:example.com i6881e e e
+// without the spaces. It's empty when the torrent has no nodes.
+//
+// A host may be a string or a []byte, see Decoder.Bytes. Anything but
+// a non-empty host and a port between 1 and 65535 is an error.
+func DHTNodes(torrent map[string]interface{}) ([]DHTNode, error) {
+	v, ok := torrent["nodes"]
+	if !ok {
+		return []DHTNode{}, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("nodes: expected list, got %T", v)
+	}
+
+	nodes := make([]DHTNode, 0, len(list))
+	for i, e := range list {
+		pair, ok := e.([]interface{})
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("nodes[%d]: not a [host, port] pair", i)
+		}
+
+		var host string
+		switch h := pair[0].(type) {
+		case string, []byte:
+			host = toString(h)
+		}
+		if host == "" {
+			return nil, fmt.Errorf("nodes[%d]: host is not a non-empty string", i)
+		}
+		port, ok := pair[1].(int)
+		if !ok || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("nodes[%d]: port is not an int between 1 and 65535", i)
+		}
+
+		nodes = append(nodes, DHTNode{Host: host, Port: port})
+	}
+
+	return nodes, nil
+}
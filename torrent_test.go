@@ -0,0 +1,308 @@
+package bencode
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePieceCount(t *testing.T) {
+	hashes := func(n int) string { return strings.Repeat("h", n*pieceHashSize) }
+
+	tests := []struct {
+		name        string
+		info        map[string]interface{}
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:        "valid: single file, last piece is short",
+			info:        map[string]interface{}{"piece length": 10, "length": 25, "pieces": hashes(3)},
+			expectedErr: "",
+		},
+		{
+			name:        "valid: single file, whole pieces",
+			info:        map[string]interface{}{"piece length": 10, "length": 20, "pieces": hashes(2)},
+			expectedErr: "",
+		},
+		{
+			name: "valid: multiple files",
+			info: map[string]interface{}{
+				"piece length": 10,
+				"files": []interface{}{
+					map[string]interface{}{"length": 15},
+					map[string]interface{}{"length": 6},
+				},
+				"pieces": hashes(3),
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "valid: empty file",
+			info:        map[string]interface{}{"piece length": 10, "length": 0, "pieces": ""},
+			expectedErr: "",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: too few hashes",
+			info:        map[string]interface{}{"piece length": 10, "length": 25, "pieces": hashes(2)},
+			expectedErr: "piece count mismatch: 25 bytes in pieces of 10 need 3 hashes, got 2",
+		},
+		{
+			name:        "invalid: too many hashes",
+			info:        map[string]interface{}{"piece length": 10, "length": 20, "pieces": hashes(3)},
+			expectedErr: "piece count mismatch: 20 bytes in pieces of 10 need 2 hashes, got 3",
+		},
+		{
+			name:        "invalid: partial hash",
+			info:        map[string]interface{}{"piece length": 10, "length": 20, "pieces": "abc"},
+			expectedErr: "pieces: 3 bytes is not a whole number of hashes",
+		},
+		{
+			name:        "invalid: zero piece length",
+			info:        map[string]interface{}{"piece length": 0, "length": 20, "pieces": ""},
+			expectedErr: "piece length: missing or not a positive int",
+		},
+		{
+			name:        "invalid: no length or files",
+			info:        map[string]interface{}{"piece length": 10, "pieces": ""},
+			expectedErr: "neither length nor files are present",
+		},
+		{
+			name: "invalid: file without a length",
+			info: map[string]interface{}{
+				"piece length": 10,
+				"files":        []interface{}{map[string]interface{}{}},
+				"pieces":       "",
+			},
+			expectedErr: "files[0].length: missing or not a non-negative int",
+		},
+		{
+			name: "invalid: total length overflows",
+			info: map[string]interface{}{
+				"piece length": 10,
+				"files": []interface{}{
+					map[string]interface{}{"length": math.MaxInt - 1},
+					map[string]interface{}{"length": math.MaxInt - 1},
+				},
+				"pieces": "",
+			},
+			expectedErr: "files[1].length: total length overflows int",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidatePieceCount(map[string]interface{}{"info": test.info})
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidatePieceCountNoInfo(t *testing.T) {
+	assert.EqualError(t, ValidatePieceCount(map[string]interface{}{}), "info: missing or not a dict")
+}
+
+func TestValidatePieceCountBytes(t *testing.T) {
+	d := NewDecoder(strings.NewReader("d4:infod6:lengthi25e12:piece lengthi10e6:pieces60:" + strings.Repeat("h", 60) + "ee"))
+	d.Bytes = true
+	v, err := d.Decode()
+	assert.NoError(t, err)
+
+	assert.NoError(t, ValidatePieceCount(v.(map[string]interface{})))
+}
+
+func TestWebSeeds(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            map[string]interface{}
+		expectedSeeds []string
+		expectedErr   string
+	}{
+		// Positive cases
+		{
+			name:          "valid: no url-list",
+			in:            map[string]interface{}{},
+			expectedSeeds: []string{},
+		},
+		{
+			name:          "valid: a single string",
+			in:            map[string]interface{}{"url-list": "http://a/"},
+			expectedSeeds: []string{"http://a/"},
+		},
+		{
+			name:          "valid: an empty string",
+			in:            map[string]interface{}{"url-list": ""},
+			expectedSeeds: []string{},
+		},
+		{
+			name:          "valid: a list",
+			in:            map[string]interface{}{"url-list": []interface{}{"http://a/", []byte("http://b/")}},
+			expectedSeeds: []string{"http://a/", "http://b/"},
+		},
+		{
+			name:          "valid: []byte",
+			in:            map[string]interface{}{"url-list": []byte("http://a/")},
+			expectedSeeds: []string{"http://a/"},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: an int",
+			in:          map[string]interface{}{"url-list": 1},
+			expectedErr: "url-list: expected string or list, got int",
+		},
+		{
+			name:        "invalid: an int in the list",
+			in:          map[string]interface{}{"url-list": []interface{}{"http://a/", 1}},
+			expectedErr: "url-list[1]: expected string, got int",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seeds, err := WebSeeds(test.in)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedSeeds, seeds)
+			}
+		})
+	}
+}
+
+func TestCreatedAt(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           map[string]interface{}
+		expectedTime time.Time
+		expectedOK   bool
+	}{
+		{
+			name:         "a Unix time",
+			in:           map[string]interface{}{"creation date": 1700000000},
+			expectedTime: time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC),
+			expectedOK:   true,
+		},
+		{
+			name: "absent",
+			in:   map[string]interface{}{},
+		},
+		{
+			name: "not an int",
+			in:   map[string]interface{}{"creation date": "1700000000"},
+		},
+		{
+			name: "zero",
+			in:   map[string]interface{}{"creation date": 0},
+		},
+		{
+			name: "negative",
+			in:   map[string]interface{}{"creation date": -1},
+		},
+	}
+	// On 32 bits an int can't go past 2038.
+	if ms := int64(1700000000000); strconv.IntSize == 64 {
+		tests = append(tests, struct {
+			name         string
+			in           map[string]interface{}
+			expectedTime time.Time
+			expectedOK   bool
+		}{
+			name: "in milliseconds",
+			in:   map[string]interface{}{"creation date": int(ms)},
+		})
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			created, ok := CreatedAt(test.in)
+
+			assert.Equal(t, test.expectedOK, ok)
+			assert.Equal(t, test.expectedTime, created)
+		})
+	}
+}
+
+func TestDHTNodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		expectedNodes []DHTNode
+		expectedErr   string
+	}{
+		// Positive cases
+		{
+			name:          "valid: no nodes",
+			in:            "de",
+			expectedNodes: []DHTNode{},
+		},
+		{
+			name:          "valid: two nodes",
+			in:            "d5:nodesll9:127.0.0.1i6881eel11:example.comi1eeee",
+			expectedNodes: []DHTNode{{Host: "127.0.0.1", Port: 6881}, {Host: "example.com", Port: 1}},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: not a list",
+			in:          "d5:nodesi1ee",
+			expectedErr: "nodes: expected list, got int",
+		},
+		{
+			name:        "invalid: not a pair",
+			in:          "d5:nodesll9:127.0.0.1eee",
+			expectedErr: "nodes[0]: not a [host, port] pair",
+		},
+		{
+			name:        "invalid: empty host",
+			in:          "d5:nodesll9:127.0.0.1i1eel0:i1eeee",
+			expectedErr: "nodes[1]: host is not a non-empty string",
+		},
+		{
+			name:        "invalid: host is an int",
+			in:          "d5:nodeslli1ei1eeee",
+			expectedErr: "nodes[0]: host is not a non-empty string",
+		},
+		{
+			name:        "invalid: port out of range",
+			in:          "d5:nodesll9:127.0.0.1i65536eeee",
+			expectedErr: "nodes[0]: port is not an int between 1 and 65535",
+		},
+		{
+			name:        "invalid: port is a string",
+			in:          "d5:nodesll9:127.0.0.14:6881eee",
+			expectedErr: "nodes[0]: port is not an int between 1 and 65535",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(test.in))
+			d.Bytes = true
+			v, err := d.Decode()
+			assert.NoError(t, err)
+
+			nodes, err := DHTNodes(v.(map[string]interface{}))
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedNodes, nodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package bencode
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LeavesOptions tunes what StringLeavesWith collects.
+type LeavesOptions struct {
+	// Exclude holds dictionary keys whose values are skipped entirely,
+	// e.g. "pieces", which is binary and useless for indexing.
+	Exclude map[string]bool
+	// Unique drops repeated strings, keeping the first occurrence.
+	Unique bool
+}
+
+// StringLeaves walks a decoded value and collects every string in it.
+//
+// Dictionary keys are not leaves, only values are collected,
+// []byte ones included, see Decoder.Bytes.
+// Dictionaries are walked in sorted key order, so the result
+// is the same for the same input.
+//
+// Example:
+// d4:name3:foo5:filesl3:bar3:bazee
+// gives []string{"bar", "baz", "foo"}.
+func StringLeaves(v interface{}) []string {
+	return StringLeavesWith(v, LeavesOptions{})
+}
+
+// StringLeavesWith is StringLeaves with options.
+func StringLeavesWith(v interface{}, o LeavesOptions) []string {
+	leaves := []string{}
+	seen := make(map[string]bool)
+
+	leaf := func(s string) {
+		if o.Unique {
+			if seen[s] {
+				return
+			}
+			seen[s] = true
+		}
+		leaves = append(leaves, s)
+	}
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			leaf(v)
+		case []byte:
+			leaf(string(v))
+		case []interface{}:
+			for _, e := range v {
+				walk(e)
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				if o.Exclude[k] {
+					continue
+				}
+				walk(v[k])
+			}
+		}
+	}
+	walk(v)
+
+	return leaves
+}
+
+// Flatten turns a decoded value into a flat map of its leaves
+// keyed by their paths.
+//
+// Dictionary keys are joined with dots and list indices are put
+// in brackets, so the length of the first file of a torrent
+// is at info.files[0].length. A dot, a bracket or a backslash
+// inside a key is escaped with a backslash: the key "a.b"
+// becomes a\.b in a path.
+//
+// Empty lists and dictionaries are leaves too, so they aren't lost.
+// A value which is neither a list nor a dictionary is stored
+// under the empty path.
+func Flatten(v interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			if len(v) == 0 {
+				flat[path] = v
+			}
+			for i, e := range v {
+				walk(path+"["+strconv.Itoa(i)+"]", e)
+			}
+		case map[string]interface{}:
+			if len(v) == 0 {
+				flat[path] = v
+			}
+			for k, e := range v {
+				k = escapeKey(k)
+				if path != "" {
+					k = path + "." + k
+				}
+				walk(k, e)
+			}
+		default:
+			flat[path] = v
+		}
+	}
+	walk("", v)
+
+	return flat
+}
+
+var keyEscaper = strings.NewReplacer(`\`, `\\`, `.`, `\.`, `[`, `\[`, `]`, `\]`)
+
+func escapeKey(k string) string {
+	return keyEscaper.Replace(k)
+}
+
+// ToSyncMap copies a decoded dictionary into a sync.Map, for data
+// decoded once and then read by many goroutines.
+//
+// Only the top level is copied, nested dictionaries stay plain maps
+// and must not be modified once the sync.Map is shared.
+func ToSyncMap(d map[string]interface{}) *sync.Map {
+	m := &sync.Map{}
+	for k, v := range d {
+		m.Store(k, v)
+	}
+
+	return m
+}
+
+// HasKey reports whether path leads to a value in a decoded tree.
+//
+// Each element of path is a dictionary key, or a decimal index
+// when the value at that point is a list:
+// HasKey(torrent, "info", "files", "0", "length")
+// tells whether the first file of a torrent has a length.
+// An empty path is always there.
+func HasKey(v interface{}, path ...string) bool {
+	for _, p := range path {
+		switch c := v.(type) {
+		case map[string]interface{}:
+			e, ok := c[p]
+			if !ok {
+				return false
+			}
+			v = e
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(c) {
+				return false
+			}
+			v = c[i]
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// InfoKeys returns the sorted keys of the info dictionary
+// of a decoded torrent, or nil when there is no info dictionary.
+func InfoKeys(torrent map[string]interface{}) []string {
+	info, ok := torrent["info"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(info))
+	for k := range info {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,214 @@
+package bencode
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringLeaves(t *testing.T) {
+	tests := []struct {
+		name           string
+		in             string
+		options        LeavesOptions
+		expectedLeaves []string
+	}{
+		{
+			name:           "empty dictionary has no leaves",
+			in:             "de",
+			expectedLeaves: []string{},
+		},
+		{
+			name:           "keys are not leaves, ints are ignored",
+			in:             "d1:ai1e1:b1:ce",
+			expectedLeaves: []string{"c"},
+		},
+		{
+			name:           "nested values are walked in key order",
+			in:             "d4:name3:foo5:filesl3:bar3:bazee",
+			expectedLeaves: []string{"bar", "baz", "foo"},
+		},
+		{
+			name:           "excluded keys are skipped",
+			in:             "d4:name3:foo6:pieces4:\x00\x01\x02\x03e",
+			options:        LeavesOptions{Exclude: map[string]bool{"pieces": true}},
+			expectedLeaves: []string{"foo"},
+		},
+		{
+			name:           "duplicates are kept by default",
+			in:             "l1:a1:b1:ae",
+			expectedLeaves: []string{"a", "b", "a"},
+		},
+		{
+			name:           "duplicates are dropped with Unique",
+			in:             "l1:a1:b1:ae",
+			options:        LeavesOptions{Unique: true},
+			expectedLeaves: []string{"a", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(test.in))
+			var (
+				v   interface{}
+				err error
+			)
+			if test.in[0] == 'l' {
+				v, err = ReadList(r)
+			} else {
+				v, err = ReadDictionary(r)
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedLeaves, StringLeavesWith(v, test.options))
+		})
+	}
+}
+
+func TestStringLeavesBytes(t *testing.T) {
+	d := NewDecoder(strings.NewReader("d4:name3:foo5:filesl3:bar3:bazee"))
+	d.Bytes = true
+	v, err := d.Decode()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"bar", "baz", "foo"}, StringLeaves(v))
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           string
+		expectedFlat map[string]interface{}
+	}{
+		{
+			name:         "int is stored under the empty path",
+			in:           "i1e",
+			expectedFlat: map[string]interface{}{"": 1},
+		},
+		{
+			name: "torrent",
+			in:   "d8:announce3:url4:infod5:filesld6:lengthi5e4:pathl1:aeed6:lengthi7e4:pathl1:b1:ceeeee",
+			expectedFlat: map[string]interface{}{
+				"announce":              "url",
+				"info.files[0].length":  5,
+				"info.files[0].path[0]": "a",
+				"info.files[1].length":  7,
+				"info.files[1].path[0]": "b",
+				"info.files[1].path[1]": "c",
+			},
+		},
+		{
+			name: "empty containers are kept",
+			in:   "d1:ale1:bdee",
+			expectedFlat: map[string]interface{}{
+				"a": []interface{}{},
+				"b": map[string]interface{}{},
+			},
+		},
+		{
+			name: "special characters in keys are escaped",
+			in:   "d3:a.bd3:c[]i1ee2:d\\i2ee",
+			expectedFlat: map[string]interface{}{
+				`a\.b.c\[\]`: 1,
+				`d\\`:        2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := ReadValue(bufio.NewReader(strings.NewReader(test.in)))
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedFlat, Flatten(v))
+		})
+	}
+}
+
+func TestToSyncMap(t *testing.T) {
+	d, err := ReadDictionary(bufio.NewReader(strings.NewReader("d1:ai1e1:bd1:c1:dee")))
+	assert.NoError(t, err)
+
+	m := ToSyncMap(d)
+
+	n := 0
+	m.Range(func(k, v interface{}) bool {
+		n++
+		assert.Equal(t, d[k.(string)], v)
+		return true
+	})
+	assert.Equal(t, len(d), n)
+
+	v, ok := m.Load("b")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"c": "d"}, v)
+}
+
+func TestHasKey(t *testing.T) {
+	torrent, err := ReadDictionary(bufio.NewReader(strings.NewReader(
+		"d8:announce3:url4:infod5:filesld6:lengthi5eee4:name4:testee",
+	)))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		path     []string
+		expected bool
+	}{
+		{
+			name:     "empty path",
+			path:     nil,
+			expected: true,
+		},
+		{
+			name:     "top level key",
+			path:     []string{"announce"},
+			expected: true,
+		},
+		{
+			name:     "through a list index",
+			path:     []string{"info", "files", "0", "length"},
+			expected: true,
+		},
+		{
+			name:     "missing key",
+			path:     []string{"info", "private"},
+			expected: false,
+		},
+		{
+			name:     "index out of range",
+			path:     []string{"info", "files", "1"},
+			expected: false,
+		},
+		{
+			name:     "not an index",
+			path:     []string{"info", "files", "length"},
+			expected: false,
+		},
+		{
+			name:     "past a leaf",
+			path:     []string{"announce", "x"},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, HasKey(torrent, test.path...))
+		})
+	}
+}
+
+func TestInfoKeys(t *testing.T) {
+	torrent, err := ReadDictionary(bufio.NewReader(strings.NewReader(
+		"d4:infod6:pieces0:4:name4:test6:lengthi1e5:x_fooi1eee",
+	)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"length", "name", "pieces", "x_foo"}, InfoKeys(torrent))
+	assert.Nil(t, InfoKeys(map[string]interface{}{}))
+	assert.Nil(t, InfoKeys(map[string]interface{}{"info": "x"}))
+}
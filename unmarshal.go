@@ -0,0 +1,484 @@
+package bencode
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnmarshalTarget is returned when Unmarshal isn't given
+	// a non-nil pointer to decode into.
+	ErrUnmarshalTarget error = errors.New("unmarshal target must be a non-nil pointer")
+	// ErrUnmarshalType is returned when a value doesn't fit
+	// the Go type it's decoded into.
+	ErrUnmarshalType error = errors.New("cannot unmarshal")
+	// ErrOutOfRange is returned when an int is outside
+	// the bounds the tag of its field sets.
+	ErrOutOfRange error = errors.New("out of range")
+)
+
+// Presence tells which fields of a struct had a key in the dictionary
+// it was decoded from, by field name.
+//
+// Unmarshal fills in the first Presence field of every struct it decodes,
+// which tells a field that's absent from one that's present but zero:
+//
+//	type Info struct {
+//		Private int `bencode:"private"`
+//		Set     bencode.Presence
+//	}
+//
+// After decoding d7:privatei0ee Set.Has("Private") is true, after
+// decoding de it's false. The Presence field itself has no key,
+// Marshal leaves it out.
+type Presence map[string]bool
+
+// Has reports whether the field with the given name was present.
+func (p Presence) Has(field string) bool {
+	return p[field]
+}
+
+// Unmarshaler is implemented by types which decode themselves.
+//
+// UnmarshalBencode gets the raw bytes of exactly one valid value.
+// They may be part of a bigger input, so they have to be copied
+// to be kept after UnmarshalBencode returns.
+type Unmarshaler interface {
+	UnmarshalBencode(data []byte) error
+}
+
+// Setter is implemented by types which decode themselves from
+// a decoded value, in the form ReadValue returns it, instead of
+// parsing raw bytes like an Unmarshaler does. A type which is both
+// is decoded as a Setter.
+type Setter interface {
+	SetBencode(v interface{}) error
+}
+
+// Unmarshal decodes data, which has to hold exactly one value,
+// into the value pointed to by v.
+//
+// Dictionaries are decoded into structs field by field. The key of
+// a field is taken from its tag, like encoding/json does:
+//
+//	type Info struct {
+//		Name   string `bencode:"name"`
+//		Length int    `bencode:"length"`
+//	}
+//
+// A field without a tag is matched by its name, a field tagged
+// bencode:"-" is never set. The fields of an embedded struct count
+// as fields of the outer one, as in encoding/json. Keys without
+// a matching field are ignored and fields without a matching key keep
+// their zero value. To tell those from fields decoded from a zero
+// value, see Presence.
+//
+// An int field may set bounds on its value in its tag, e.g.
+// bencode:"piece length,min=16384,max=67108864". A value outside
+// of them is an ErrOutOfRange.
+//
+// A field tagged bencode:",raw", a RawMessage or a []byte, has no key.
+// It gets a copy of the whole dictionary the struct is decoded from,
+// e.g. to hash an info dictionary exactly as it was. Marshal leaves
+// it out.
+//
+// Ints go into any integer type they fit in, strings into strings
+// and []byte, lists into slices, like []string or []int, and
+// dictionaries into maps with string keys, like map[string]string,
+// as long as every element fits the element type. An empty list
+// gives an empty slice, never a nil one. Anything goes into
+// an interface{}, in the form ReadValue returns it.
+//
+// Map keys may also be byte arrays, e.g. map[[20]byte]Stats for
+// the files of a scrape response, keyed by info hash, as long as
+// every key has the length of the array.
+//
+// An Unmarshaler gets the bytes of its value and parses them itself,
+// e.g. a RawMessage keeps a copy of them, exactly as they are in data.
+// A Setter gets its value decoded, e.g. a []interface{} for a list.
+// Pointers are allocated as needed.
+func Unmarshal(data []byte, v interface{}) error {
+	return UnmarshalOptions{}.Unmarshal(data, v)
+}
+
+// UnmarshalOptions changes the way values are decoded into Go types.
+// The zero value decodes the way Unmarshal does.
+type UnmarshalOptions struct {
+	// CaseInsensitiveKeys matches keys to struct fields ignoring case,
+	// for torrents which have a Creation Date instead of a creation date.
+	// A key which matches a field exactly always wins over those which
+	// only match it ignoring case, and of those the last one wins.
+	// A key which matches several fields ignoring case, and none
+	// exactly, goes to the first of them. By default keys have to
+	// match exactly, as the spec demands.
+	CaseInsensitiveKeys bool
+}
+
+// Unmarshal is Unmarshal with the options o.
+func (o UnmarshalOptions) Unmarshal(data []byte, v interface{}) error {
+	rv, err := unmarshalTarget(v)
+	if err != nil {
+		return err
+	}
+
+	n, err := valueLength(data, 0)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return fmt.Errorf("%w: %d bytes after the value", ErrTrailingData, len(data)-n)
+	}
+
+	return o.unmarshalValue(data, rv)
+}
+
+// UnmarshalReader reads the one value r has to hold and decodes it
+// into the value pointed to by v, like Unmarshal does.
+//
+// The value is read as it arrives, so broken input is found out
+// without waiting for the rest of it. Anything after the value is
+// an ErrTrailingData, found out by reading a single byte past it.
+func UnmarshalReader(r io.Reader, v interface{}) error {
+	return UnmarshalOptions{}.UnmarshalReader(r, v)
+}
+
+// UnmarshalReader is UnmarshalReader with the options o.
+func (o UnmarshalOptions) UnmarshalReader(r io.Reader, v interface{}) error {
+	rv, err := unmarshalTarget(v)
+	if err != nil {
+		return err
+	}
+
+	return o.decodeInto(r, rv)
+}
+
+// DecodeInto is UnmarshalReader for a reflect.Value: it reads the one
+// value r has to hold and decodes it into rv, by the rules of Unmarshal.
+// It's meant for frameworks which deal in reflect.Values already.
+//
+// rv has to be settable, e.g. reflect.ValueOf(&v).Elem(),
+// anything else is an ErrUnmarshalTarget.
+func DecodeInto(r io.Reader, rv reflect.Value) error {
+	return UnmarshalOptions{}.decodeInto(r, rv)
+}
+
+func (o UnmarshalOptions) decodeInto(r io.Reader, rv reflect.Value) error {
+	if !rv.CanSet() {
+		return fmt.Errorf("%w: got a reflect.Value which can't be set", ErrUnmarshalTarget)
+	}
+
+	br := bufio.NewReader(r)
+	raw, err := ReadRaw(br)
+	if err != nil {
+		return err
+	}
+	if _, err := br.Peek(1); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return &SyntaxError{Offset: int64(len(raw)), Err: ErrTrailingData}
+	}
+
+	return o.unmarshalValue(raw, rv)
+}
+
+// unmarshalTarget returns what v points to,
+// as long as it's a non-nil pointer.
+func unmarshalTarget(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return reflect.Value{}, ErrUnmarshalTarget
+	}
+
+	return rv.Elem(), nil
+}
+
+// unmarshalValue decodes raw, which holds exactly one valid value, into rv.
+func (o UnmarshalOptions) unmarshalValue(raw []byte, rv reflect.Value) error {
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() {
+		switch u := rv.Addr().Interface().(type) {
+		case Setter:
+			v, _, err := DecodeOne(raw)
+			if err != nil {
+				return err
+			}
+			return u.SetBencode(v)
+		case Unmarshaler:
+			return u.UnmarshalBencode(raw)
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return o.unmarshalValue(raw, rv.Elem())
+	case reflect.Struct:
+		if raw[0] != 'd' {
+			return typeMismatch(raw, rv)
+		}
+		return o.unmarshalStruct(raw, rv)
+	case reflect.String:
+		if !isDigit(raw[0]) {
+			return typeMismatch(raw, rv)
+		}
+		rv.SetString(string(raw[bytes.IndexByte(raw, stringSeparator)+1:]))
+		return nil
+	case reflect.Map:
+		if raw[0] != 'd' || !isMapKey(rv.Type().Key()) {
+			return typeMismatch(raw, rv)
+		}
+		return o.unmarshalMap(raw, rv)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			if raw[0] != 'l' {
+				return typeMismatch(raw, rv)
+			}
+			return o.unmarshalSlice(raw, rv)
+		}
+		if !isDigit(raw[0]) {
+			return typeMismatch(raw, rv)
+		}
+		b := raw[bytes.IndexByte(raw, stringSeparator)+1:]
+		rv.SetBytes(append(make([]byte, 0, len(b)), b...))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if raw[0] != 'i' {
+			return typeMismatch(raw, rv)
+		}
+		i, err := strconv.ParseInt(string(raw[1:len(raw)-1]), 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %s into %s: out of range", ErrUnmarshalType, raw, rv.Type())
+		}
+		rv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if raw[0] != 'i' {
+			return typeMismatch(raw, rv)
+		}
+		u, err := strconv.ParseUint(string(raw[1:len(raw)-1]), 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %s into %s: out of range", ErrUnmarshalType, raw, rv.Type())
+		}
+		rv.SetUint(u)
+		return nil
+	}
+
+	v, _, err := DecodeOne(raw)
+	if err != nil {
+		return err
+	}
+	if !reflect.TypeOf(v).AssignableTo(rv.Type()) {
+		return typeMismatch(raw, rv)
+	}
+	rv.Set(reflect.ValueOf(v))
+
+	return nil
+}
+
+func (o UnmarshalOptions) unmarshalStruct(raw []byte, rv reflect.Value) error {
+	all := structFields(rv.Type())
+	fields := make(map[string]field, len(all))
+	for _, f := range all {
+		fields[f.name] = f
+	}
+
+	// folded holds the fields by their keys in lower case, exact
+	// the keys of fields set from a key which matched exactly,
+	// which one that only matches ignoring case mustn't overwrite.
+	var (
+		folded map[string]field
+		exact  map[string]bool
+	)
+	if o.CaseInsensitiveKeys {
+		folded, exact = make(map[string]field), make(map[string]bool)
+		for _, f := range all {
+			if k := strings.ToLower(f.name); folded[k].name == "" {
+				folded[k] = f
+			}
+		}
+	}
+
+	var present Presence
+	if i, ok := presenceField(rv.Type()); ok {
+		present = make(Presence)
+		rv.Field(i).Set(reflect.ValueOf(present))
+	}
+	if i, ok := rawField(rv.Type()); ok {
+		b := append(make([]byte, 0, len(raw)), raw...)
+		rv.Field(i).Set(reflect.ValueOf(b).Convert(rv.Field(i).Type()))
+	}
+
+	return eachEntry(raw, func(key string, value []byte) error {
+		f, ok := fields[key]
+		if ok && exact != nil {
+			exact[f.name] = true
+		}
+		if !ok {
+			if f, ok = folded[strings.ToLower(key)]; !ok || exact[f.name] {
+				return nil
+			}
+		}
+		fv, ok := fieldByIndex(rv, f.index, true)
+		if !ok {
+			return nil
+		}
+
+		if err := o.unmarshalValue(value, fv); err != nil {
+			return fmt.Errorf("field %s: %w", f.fieldName, err)
+		}
+		if err := checkRange(fv, f); err != nil {
+			return fmt.Errorf("field %s: %w", f.fieldName, err)
+		}
+		if present != nil {
+			present[f.fieldName] = true
+		}
+
+		return nil
+	})
+}
+
+// checkRange makes sure the int field rv, just decoded,
+// is within the bounds of f, if it has any.
+func checkRange(rv reflect.Value, f field) error {
+	if f.min == "" && f.max == "" {
+		return nil
+	}
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	var (
+		x        int64
+		u        uint64
+		unsigned bool
+	)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, unsigned = rv.Uint(), true
+	default:
+		return fmt.Errorf("min and max apply to ints, not %s", rv.Type())
+	}
+
+	if f.min != "" {
+		min, err := strconv.ParseInt(f.min, 10, 64)
+		if err != nil {
+			return fmt.Errorf("min=%s in tag: not an int", f.min)
+		}
+		if unsigned && min > 0 && u < uint64(min) || !unsigned && x < min {
+			return fmt.Errorf("%w: %v is less than the min of %d", ErrOutOfRange, rv.Interface(), min)
+		}
+	}
+	if f.max != "" {
+		max, err := strconv.ParseInt(f.max, 10, 64)
+		if err != nil {
+			return fmt.Errorf("max=%s in tag: not an int", f.max)
+		}
+		if unsigned && (max < 0 || u > uint64(max)) || !unsigned && x > max {
+			return fmt.Errorf("%w: %v is more than the max of %d", ErrOutOfRange, rv.Interface(), max)
+		}
+	}
+
+	return nil
+}
+
+func (o UnmarshalOptions) unmarshalMap(raw []byte, rv reflect.Value) error {
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+
+	return eachEntry(raw, func(key string, value []byte) error {
+		k, err := mapKey(key, rv.Type().Key())
+		if err != nil {
+			return fmt.Errorf("dict key %q: %w", key, err)
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		if err := o.unmarshalValue(value, elem); err != nil {
+			return fmt.Errorf("dict key %q: %w", key, err)
+		}
+		rv.SetMapIndex(k, elem)
+
+		return nil
+	})
+}
+
+// isMapKey tells whether dictionary keys go into map keys of type t:
+// string types, and byte arrays like the [20]byte of an info hash.
+func isMapKey(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+// mapKey converts a dictionary key to the map key type t. A byte array
+// takes a key of exactly its length.
+func mapKey(key string, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.String {
+		return reflect.ValueOf(key).Convert(t), nil
+	}
+	if len(key) != t.Len() {
+		return reflect.Value{}, fmt.Errorf("%w: %d byte key into %s", ErrUnmarshalType, len(key), t)
+	}
+
+	k := reflect.New(t).Elem()
+	reflect.Copy(k, reflect.ValueOf(key))
+
+	return k, nil
+}
+
+func (o UnmarshalOptions) unmarshalSlice(raw []byte, rv reflect.Value) error {
+	s := reflect.MakeSlice(rv.Type(), 0, 0)
+	i := 0
+	_, err := (&walker{data: raw}).elements(0, 0, func(start, end int) error {
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		if err := o.unmarshalValue(raw[start:end], elem); err != nil {
+			return fmt.Errorf("list index %d: %w", i, err)
+		}
+		s = reflect.Append(s, elem)
+		i++
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	rv.Set(s)
+
+	return nil
+}
+
+// eachEntry calls fn with every key of the dictionary raw holds
+// and the raw value that goes with it. A key without a value is skipped.
+func eachEntry(raw []byte, fn func(key string, value []byte) error) error {
+	_, err := (&walker{data: raw}).entries(0, 0, func(key []byte, start, end int) error {
+		if start == end {
+			return nil
+		}
+		return fn(string(key), raw[start:end])
+	})
+
+	return err
+}
+
+func typeMismatch(raw []byte, rv reflect.Value) error {
+	var kind string
+	switch raw[0] {
+	case 'i':
+		kind = "int"
+	case 'l':
+		kind = "list"
+	case 'd':
+		kind = "dict"
+	default:
+		kind = "string"
+	}
+
+	return fmt.Errorf("%w: %s into %s", ErrUnmarshalType, kind, rv.Type())
+}
@@ -0,0 +1,807 @@
+package bencode
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testFile struct {
+	Length int           `bencode:"length"`
+	Path   []interface{} `bencode:"path"`
+}
+
+type testInfo struct {
+	Name        string      `bencode:"name"`
+	PieceLength int64       `bencode:"piece length"`
+	Length      *int        `bencode:"length"`
+	Private     uint8       `bencode:"private"`
+	Files       interface{} `bencode:"files"`
+}
+
+type testTorrent struct {
+	Announce string `bencode:"announce"`
+	Info     testInfo
+	Comment  string `bencode:"-"`
+	Other    string
+	hidden   string
+}
+
+func TestUnmarshal(t *testing.T) {
+	length := 7
+
+	tests := []struct {
+		name        string
+		in          string
+		expected    testTorrent
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:     "valid: empty dict leaves zero values",
+			in:       "de",
+			expected: testTorrent{},
+		},
+		{
+			name: "valid: torrent",
+			in: "d8:announce3:url4:Infod6:lengthi7e4:name4:test12:piece lengthi16384e" +
+				"7:privatei1eee",
+			expected: testTorrent{
+				Announce: "url",
+				Info: testInfo{
+					Name:        "test",
+					PieceLength: 16384,
+					Length:      &length,
+					Private:     1,
+				},
+			},
+		},
+		{
+			name: "valid: untagged field is matched by name, unknown keys are ignored",
+			in:   "d5:Other1:x7:Comment1:y6:hidden1:z7:unknowni1ee",
+			expected: testTorrent{
+				Other: "x",
+			},
+		},
+		{
+			name: "valid: interface{} field takes any value",
+			in:   "d4:Infod5:filesld6:lengthi1eeeee",
+			expected: testTorrent{
+				Info: testInfo{
+					Files: []interface{}{map[string]interface{}{"length": 1}},
+				},
+			},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: not a dict",
+			in:          "li1ee",
+			expectedErr: "cannot unmarshal: list into bencode.testTorrent",
+		},
+		{
+			name:        "invalid: string into an int",
+			in:          "d4:Infod4:name1:x12:piece length1:xee",
+			expectedErr: "field Info: field PieceLength: cannot unmarshal: string into int64",
+		},
+		{
+			name:        "invalid: int out of range",
+			in:          "d4:Infod7:privatei256eee",
+			expectedErr: "field Info: field Private: cannot unmarshal: i256e into uint8: out of range",
+		},
+		{
+			name:        "invalid: broken input",
+			in:          "d8:announce",
+			expectedErr: "unexpected EOF",
+		},
+		{
+			name:        "invalid: trailing data",
+			in:          "dei1e",
+			expectedErr: "trailing data: 3 bytes after the value",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v testTorrent
+			err := Unmarshal([]byte(test.in), &v)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, v)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFileList(t *testing.T) {
+	var files struct {
+		Files []interface{} `bencode:"files"`
+	}
+	err := Unmarshal([]byte("d5:filesld6:lengthi1e4:pathl1:aeeee"), &files)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"length": 1, "path": []interface{}{"a"}},
+	}, files.Files)
+
+	var f testFile
+	err = Unmarshal([]byte("d6:lengthi1e4:pathl1:aee"), &f)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testFile{Length: 1, Path: []interface{}{"a"}}, f)
+}
+
+func TestUnmarshalTarget(t *testing.T) {
+	var v testTorrent
+
+	assert.ErrorIs(t, Unmarshal([]byte("de"), v), ErrUnmarshalTarget)
+	assert.ErrorIs(t, Unmarshal([]byte("de"), nil), ErrUnmarshalTarget)
+	assert.ErrorIs(t, Unmarshal([]byte("de"), (*testTorrent)(nil)), ErrUnmarshalTarget)
+}
+
+func TestUnmarshalPresence(t *testing.T) {
+	type info struct {
+		Name    string `bencode:"name"`
+		Private int    `bencode:"private"`
+		Set     Presence
+	}
+	type torrent struct {
+		Info info `bencode:"info"`
+	}
+
+	tests := []struct {
+		name            string
+		in              string
+		expectedPrivate bool
+		expectedName    bool
+	}{
+		{
+			name:            "present and zero",
+			in:              "d4:infod7:privatei0eee",
+			expectedPrivate: true,
+		},
+		{
+			name: "absent",
+			in:   "d4:infodee",
+		},
+		{
+			name:            "both present",
+			in:              "d4:infod4:name1:x7:privatei1eee",
+			expectedPrivate: true,
+			expectedName:    true,
+		},
+		{
+			name: "unknown keys don't count",
+			in:   "d4:infod3:Set1:x5:otheri1eee",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v torrent
+			err := Unmarshal([]byte(test.in), &v)
+
+			assert.NoError(t, err)
+			assert.NotNil(t, v.Info.Set)
+			assert.Equal(t, test.expectedPrivate, v.Info.Set.Has("Private"))
+			assert.Equal(t, test.expectedName, v.Info.Set.Has("Name"))
+		})
+	}
+}
+
+func TestUnmarshalRawField(t *testing.T) {
+	type info struct {
+		Name string     `bencode:"name"`
+		Raw  RawMessage `bencode:",raw"`
+	}
+	type torrent struct {
+		Info  info   `bencode:"info"`
+		Infos []info `bencode:"infos"`
+		Bytes []byte `bencode:"whole,raw"`
+	}
+
+	in := []byte("d4:infod6:lengthi1e4:name1:ae5:infosld4:name1:beee")
+
+	var v torrent
+	assert.NoError(t, Unmarshal(in, &v))
+	assert.Equal(t, torrent{
+		Info:  info{Name: "a", Raw: RawMessage("d6:lengthi1e4:name1:ae")},
+		Infos: []info{{Name: "b", Raw: RawMessage("d4:name1:be")}},
+		Bytes: in,
+	}, v)
+
+	// A copy, not a part of the input.
+	in[len("d4:infod6:lengthi1e4:name1:")] = 'x'
+	assert.Equal(t, RawMessage("d6:lengthi1e4:name1:ae"), v.Info.Raw)
+
+	// Raw fields have no key of their own.
+	out, err := Marshal(v.Info)
+	assert.NoError(t, err)
+	assert.Equal(t, "d4:name1:ae", string(out))
+}
+
+func TestUnmarshalRange(t *testing.T) {
+	type info struct {
+		PieceLength int    `bencode:"piece length,min=16384,max=67108864"`
+		Private     *uint8 `bencode:"private,omitempty,max=1"`
+		Files       uint   `bencode:"files,min=1"`
+	}
+
+	tests := []struct {
+		name        string
+		in          string
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name: "valid: within bounds",
+			in:   "d5:filesi1e12:piece lengthi16384e7:privatei1ee",
+		},
+		{
+			name: "valid: absent fields are not checked",
+			in:   "de",
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: below the min",
+			in:          "d12:piece lengthi1024ee",
+			expectedErr: "field PieceLength: out of range: 1024 is less than the min of 16384",
+		},
+		{
+			name:        "invalid: above the max",
+			in:          "d12:piece lengthi134217728ee",
+			expectedErr: "field PieceLength: out of range: 134217728 is more than the max of 67108864",
+		},
+		{
+			name:        "invalid: pointer above the max",
+			in:          "d7:privatei2ee",
+			expectedErr: "field Private: out of range: 2 is more than the max of 1",
+		},
+		{
+			name:        "invalid: unsigned below the min",
+			in:          "d5:filesi0ee",
+			expectedErr: "field Files: out of range: 0 is less than the min of 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v info
+			err := Unmarshal([]byte(test.in), &v)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.ErrorIs(t, err, ErrOutOfRange)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalRangeTag(t *testing.T) {
+	var bad struct {
+		N int `bencode:"n,min=one"`
+	}
+	assert.EqualError(t, Unmarshal([]byte("d1:ni1ee"), &bad), "field N: min=one in tag: not an int")
+
+	var notInt struct {
+		S string `bencode:"s,max=1"`
+	}
+	assert.EqualError(t, Unmarshal([]byte("d1:s1:xe"), &notInt), "field S: min and max apply to ints, not string")
+}
+
+func TestUnmarshalCaseInsensitiveKeys(t *testing.T) {
+	type torrent struct {
+		Announce string `bencode:"announce"`
+		Date     int    `bencode:"creation date"`
+		Comment  string `bencode:"comment"`
+		Comment2 string `bencode:"COMMENT"`
+	}
+
+	tests := []struct {
+		name     string
+		in       string
+		expected torrent
+	}{
+		{
+			name:     "keys in any case",
+			in:       "d8:ANNOUNCE3:url13:Creation Datei1ee",
+			expected: torrent{Announce: "url", Date: 1},
+		},
+		{
+			name:     "an exact match wins over a later one",
+			in:       "d13:creation datei1e13:Creation Datei2ee",
+			expected: torrent{Date: 1},
+		},
+		{
+			name:     "an exact match wins over an earlier one",
+			in:       "d13:Creation Datei2e13:creation datei1ee",
+			expected: torrent{Date: 1},
+		},
+		{
+			name:     "the last of the matches ignoring case wins",
+			in:       "d13:Creation Datei1e13:CREATION DATEi2ee",
+			expected: torrent{Date: 2},
+		},
+		{
+			name:     "a key matching two fields ignoring case goes to the first",
+			in:       "d7:Comment1:ae",
+			expected: torrent{Comment: "a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v torrent
+			err := UnmarshalOptions{CaseInsensitiveKeys: true}.Unmarshal([]byte(test.in), &v)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, v)
+		})
+	}
+
+	// Keys match exactly by default.
+	var v torrent
+	assert.NoError(t, Unmarshal([]byte("d8:ANNOUNCE3:urle"), &v))
+	assert.Equal(t, torrent{}, v)
+
+	assert.NoError(t, UnmarshalOptions{CaseInsensitiveKeys: true}.UnmarshalReader(strings.NewReader("d8:ANNOUNCE3:urle"), &v))
+	assert.Equal(t, torrent{Announce: "url"}, v)
+}
+
+func TestMarshalSkipsPresence(t *testing.T) {
+	v := struct {
+		A   int `bencode:"a"`
+		Set Presence
+	}{A: 1, Set: Presence{"A": true}}
+
+	b, err := Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, "d1:ai1ee", string(b))
+}
+
+func TestUnmarshalReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    testFile
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:     "valid: file",
+			in:       "d6:lengthi1e4:pathl1:aee",
+			expected: testFile{Length: 1, Path: []interface{}{"a"}},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: trailing data",
+			in:          "d6:lengthi1eei2e",
+			expectedErr: "offset 13: trailing data",
+		},
+		{
+			name:        "invalid: broken input",
+			in:          "d6:length",
+			expectedErr: "unexpected EOF",
+		},
+		{
+			name:        "invalid: type mismatch",
+			in:          "d6:length1:xe",
+			expectedErr: "field Length: cannot unmarshal: string into int",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v testFile
+			err := UnmarshalReader(iotest.HalfReader(strings.NewReader(test.in)), &v)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, v)
+			}
+		})
+	}
+}
+
+func TestUnmarshalReaderOpenStream(t *testing.T) {
+	// The writer never closes, so reading the rest of the stream would block.
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("d6:lengthi1eei2e"))
+	}()
+
+	var v testFile
+	err := UnmarshalReader(pr, &v)
+	assert.ErrorIs(t, err, ErrTrailingData)
+}
+
+type testKey string
+
+func TestUnmarshalMap(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		into        func() interface{}
+		expected    interface{}
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:     "valid: map[string]string",
+			in:       "d1:a1:x1:b1:ye",
+			into:     func() interface{} { return &map[string]string{} },
+			expected: &map[string]string{"a": "x", "b": "y"},
+		},
+		{
+			name:     "valid: map[string]int",
+			in:       "d8:completei5e10:incompletei1ee",
+			into:     func() interface{} { return new(map[string]int) },
+			expected: &map[string]int{"complete": 5, "incomplete": 1},
+		},
+		{
+			name:     "valid: map[string][]byte",
+			in:       "d6:pieces2:\x00\xffe",
+			into:     func() interface{} { return new(map[string][]byte) },
+			expected: &map[string][]byte{"pieces": {0x00, 0xff}},
+		},
+		{
+			name:     "valid: empty dict",
+			in:       "de",
+			into:     func() interface{} { return new(map[string]string) },
+			expected: &map[string]string{},
+		},
+		{
+			name: "valid: map field of a struct",
+			in:   "d5:filesd1:ai1eee",
+			into: func() interface{} {
+				return &struct {
+					Files map[string]int `bencode:"files"`
+				}{}
+			},
+			expected: &struct {
+				Files map[string]int `bencode:"files"`
+			}{Files: map[string]int{"a": 1}},
+		},
+		{
+			name: "valid: map keyed by byte arrays",
+			in:   "d5:filesd4:\x00\x01\x02\x03d8:completei5eeee",
+			into: func() interface{} {
+				return &struct {
+					Files map[[4]byte]map[string]int `bencode:"files"`
+				}{}
+			},
+			expected: &struct {
+				Files map[[4]byte]map[string]int `bencode:"files"`
+			}{Files: map[[4]byte]map[string]int{{0, 1, 2, 3}: {"complete": 5}}},
+		},
+		{
+			name:     "valid: map keyed by a string type",
+			in:       "d1:ai1ee",
+			into:     func() interface{} { return new(map[testKey]int) },
+			expected: &map[testKey]int{"a": 1},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: int in a map[string]string",
+			in:          "d1:a1:x1:bi1ee",
+			into:        func() interface{} { return new(map[string]string) },
+			expectedErr: `dict key "b": cannot unmarshal: int into string`,
+		},
+		{
+			name:        "invalid: string in a map[string]int",
+			in:          "d1:a1:xe",
+			into:        func() interface{} { return new(map[string]int) },
+			expectedErr: `dict key "a": cannot unmarshal: string into int`,
+		},
+		{
+			name:        "invalid: list in a map[string][]byte",
+			in:          "d1:alee",
+			into:        func() interface{} { return new(map[string][]byte) },
+			expectedErr: `dict key "a": cannot unmarshal: list into []uint8`,
+		},
+		{
+			name:        "invalid: not a dict",
+			in:          "le",
+			into:        func() interface{} { return new(map[string]string) },
+			expectedErr: "cannot unmarshal: list into map[string]string",
+		},
+		{
+			name:        "invalid: key too short for the byte array",
+			in:          "d3:abci1ee",
+			into:        func() interface{} { return new(map[[20]byte]int) },
+			expectedErr: `dict key "abc": cannot unmarshal: 3 byte key into [20]uint8`,
+		},
+		{
+			name:        "invalid: key type",
+			in:          "de",
+			into:        func() interface{} { return new(map[int]string) },
+			expectedErr: "cannot unmarshal: dict into map[int]string",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := test.into()
+			err := Unmarshal([]byte(test.in), v)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.ErrorIs(t, err, ErrUnmarshalType)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, v)
+			}
+		})
+	}
+}
+
+func TestUnmarshalSlice(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		into        func() interface{}
+		expected    interface{}
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name:     "valid: []string",
+			in:       "l4:spam4:eggse",
+			into:     func() interface{} { return new([]string) },
+			expected: &[]string{"spam", "eggs"},
+		},
+		{
+			name:     "valid: []int",
+			in:       "li1ei-2ee",
+			into:     func() interface{} { return new([]int) },
+			expected: &[]int{1, -2},
+		},
+		{
+			name:     "valid: empty list is not nil",
+			in:       "le",
+			into:     func() interface{} { return new([]string) },
+			expected: &[]string{},
+		},
+		{
+			name:     "valid: existing elements are replaced",
+			in:       "l1:ae",
+			into:     func() interface{} { return &[]string{"x", "y"} },
+			expected: &[]string{"a"},
+		},
+		{
+			name:     "valid: announce-list",
+			in:       "ll3:url4:url2el4:url3ee",
+			into:     func() interface{} { return new([][]string) },
+			expected: &[][]string{{"url", "url2"}, {"url3"}},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: int in a []string",
+			in:          "l1:ai1ee",
+			into:        func() interface{} { return new([]string) },
+			expectedErr: "list index 1: cannot unmarshal: int into string",
+		},
+		{
+			name:        "invalid: nested mismatch",
+			in:          "ll1:aeli1eee",
+			into:        func() interface{} { return new([][]string) },
+			expectedErr: "list index 1: list index 0: cannot unmarshal: int into string",
+		},
+		{
+			name:        "invalid: not a list",
+			in:          "de",
+			into:        func() interface{} { return new([]int) },
+			expectedErr: "cannot unmarshal: dict into []int",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := test.into()
+			err := Unmarshal([]byte(test.in), v)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.ErrorIs(t, err, ErrUnmarshalType)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, v)
+			}
+		})
+	}
+}
+
+// testHexID decodes itself from a string of hex digits.
+type testHexID []byte
+
+func (id *testHexID) UnmarshalBencode(data []byte) error {
+	var s string
+	if err := Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*id = b
+
+	return nil
+}
+
+func TestUnmarshaler(t *testing.T) {
+	type peer struct {
+		ID    testHexID   `bencode:"id"`
+		Other *testHexID  `bencode:"other"`
+		IDs   []testHexID `bencode:"ids"`
+		Raw   RawMessage  `bencode:"raw"`
+	}
+
+	tests := []struct {
+		name        string
+		in          string
+		expected    peer
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name: "valid: fields, pointers and slice elements",
+			in:   "d2:id4:beef3:idsl2:ab2:cde5:other2:003:rawli1eee",
+			expected: peer{
+				ID:    testHexID{0xbe, 0xef},
+				Other: &testHexID{0x00},
+				IDs:   []testHexID{{0xab}, {0xcd}},
+				Raw:   RawMessage("li1ee"),
+			},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: UnmarshalBencode fails",
+			in:          "d2:id2:zze",
+			expectedErr: "field ID: encoding/hex: invalid byte: U+007A 'z'",
+		},
+		{
+			name:        "invalid: UnmarshalBencode gets the wrong type",
+			in:          "d2:idi1ee",
+			expectedErr: "field ID: cannot unmarshal: int into string",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v peer
+			err := Unmarshal([]byte(test.in), &v)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, v)
+			}
+		})
+	}
+}
+
+// testTracker decodes itself from an announce URL or a list of them.
+type testTracker []string
+
+func (tr *testTracker) SetBencode(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		*tr = testTracker{v}
+	case []interface{}:
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("tracker: %T is not a string", e)
+			}
+			*tr = append(*tr, s)
+		}
+	default:
+		return fmt.Errorf("tracker: %T is not a string or a list", v)
+	}
+
+	return nil
+}
+
+// testBoth is a Setter and an Unmarshaler.
+type testBoth string
+
+func (b *testBoth) SetBencode(v interface{}) error {
+	*b = "set"
+	return nil
+}
+
+func (b *testBoth) UnmarshalBencode(data []byte) error {
+	*b = "unmarshaled"
+	return nil
+}
+
+func TestSetter(t *testing.T) {
+	type torrent struct {
+		Announce testTracker   `bencode:"announce"`
+		Backup   *testTracker  `bencode:"backup"`
+		Tiers    []testTracker `bencode:"tiers"`
+		Both     testBoth      `bencode:"both"`
+	}
+
+	tests := []struct {
+		name        string
+		in          string
+		expected    torrent
+		expectedErr string
+	}{
+		// Positive cases
+		{
+			name: "valid: fields, pointers and slice elements",
+			in:   "d8:announce1:a6:backupl1:b1:ce4:both0:5:tiersl1:dl1:eeee",
+			expected: torrent{
+				Announce: testTracker{"a"},
+				Backup:   &testTracker{"b", "c"},
+				Tiers:    []testTracker{{"d"}, {"e"}},
+				Both:     "set",
+			},
+		},
+
+		// Negative cases
+		{
+			name:        "invalid: SetBencode fails",
+			in:          "d8:announcei1ee",
+			expectedErr: "field Announce: tracker: int is not a string or a list",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v torrent
+			err := Unmarshal([]byte(test.in), &v)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, v)
+			}
+		})
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	var info testInfo
+	err := DecodeInto(strings.NewReader("d4:name4:test12:piece lengthi16384ee"), reflect.ValueOf(&info).Elem())
+	assert.NoError(t, err)
+	assert.Equal(t, testInfo{Name: "test", PieceLength: 16384}, info)
+
+	// A struct field, the way a framework walking a struct gets to it.
+	var torrent testTorrent
+	err = DecodeInto(strings.NewReader("d4:name4:teste"), reflect.ValueOf(&torrent).Elem().FieldByName("Info"))
+	assert.NoError(t, err)
+	assert.Equal(t, "test", torrent.Info.Name)
+
+	err = DecodeInto(strings.NewReader("i1ei2e"), reflect.ValueOf(&info.Private).Elem())
+	assert.ErrorIs(t, err, ErrTrailingData)
+}
+
+func TestDecodeIntoNotSettable(t *testing.T) {
+	for _, rv := range []reflect.Value{{}, reflect.ValueOf(1), reflect.ValueOf(testInfo{}).Field(0)} {
+		err := DecodeInto(strings.NewReader("i1e"), rv)
+		assert.ErrorIs(t, err, ErrUnmarshalTarget)
+	}
+}
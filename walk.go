@@ -0,0 +1,184 @@
+package bencode
+
+import (
+	"bytes"
+	"io"
+)
+
+// walker checks values in a slice without decoding them. It's what
+// Unmarshal, LazyDict, RawMessage and NormalizeIntegers are built on,
+// so they all accept the same input: that of ReadValue, with ints
+// of any size. Lists and dictionaries may nest DefaultMaxDepth deep.
+type walker struct {
+	data []byte
+	// onInt, if not nil, is called with the offsets of every int
+	// and its canonical form, as it's walked over.
+	onInt func(start, end int, canonical []byte)
+}
+
+// valueLength returns the length of the value at the head of data,
+// which is nested in depth lists and dictionaries.
+func valueLength(data []byte, depth int) (int, error) {
+	return (&walker{data: data}).value(0, depth)
+}
+
+// value walks the value at pos, which is nested in depth
+// lists and dictionaries, and returns the offset right after it.
+func (w *walker) value(pos, depth int) (int, error) {
+	if pos == len(w.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	switch w.data[pos] {
+	case 'i':
+		end := bytes.IndexByte(w.data[pos:], 'e')
+		if end < 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		end += pos + 1
+		canonical, err := canonicalInt(w.data[pos+1 : end-1])
+		if err != nil {
+			return 0, err
+		}
+		if w.onInt != nil {
+			w.onInt(pos, end, canonical)
+		}
+		return end, nil
+	case 'l':
+		return w.elements(pos, depth, nil)
+	case 'd':
+		return w.entries(pos, depth, nil)
+	default:
+		n, err := stringLength(w.data[pos:])
+		return pos + n, err
+	}
+}
+
+// elements walks the list at pos and calls fn, if not nil, with
+// the offsets of every element. It returns the offset right after the list.
+func (w *walker) elements(pos, depth int, fn func(start, end int) error) (int, error) {
+	if depth >= DefaultMaxDepth {
+		return 0, ErrMaxDepthExceeded
+	}
+	if pos == len(w.data) || w.data[pos] != 'l' {
+		return 0, ErrListInvalid
+	}
+
+	pos++
+	for {
+		if pos == len(w.data) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if w.data[pos] == 'e' {
+			return pos + 1, nil
+		}
+
+		end, err := w.value(pos, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		if fn != nil {
+			if err := fn(pos, end); err != nil {
+				return 0, err
+			}
+		}
+		pos = end
+	}
+}
+
+// entries walks the dictionary at pos and calls fn, if not nil, with
+// every key and the offsets of its value. It returns the offset right
+// after the dictionary.
+func (w *walker) entries(pos, depth int, fn func(key []byte, start, end int) error) (int, error) {
+	if depth >= DefaultMaxDepth {
+		return 0, ErrMaxDepthExceeded
+	}
+	if pos == len(w.data) || w.data[pos] != 'd' {
+		return 0, ErrDictInvalid
+	}
+
+	pos++
+	for {
+		if pos == len(w.data) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if w.data[pos] == 'e' {
+			return pos + 1, nil
+		}
+
+		n, err := stringLength(w.data[pos:])
+		if err != nil {
+			return 0, err
+		}
+		key := w.data[pos+bytes.IndexByte(w.data[pos:], stringSeparator)+1 : pos+n]
+		pos += n
+
+		// A key right before the end has no value, which is what
+		// ReadDictionary reads as a nil one. fn gets an empty range.
+		end := pos
+		if pos != len(w.data) && w.data[pos] != 'e' {
+			if end, err = w.value(pos, depth+1); err != nil {
+				return 0, err
+			}
+		}
+		if fn != nil {
+			if err := fn(key, pos, end); err != nil {
+				return 0, err
+			}
+		}
+		pos = end
+	}
+}
+
+// stringLength returns the length of the string at the head of data,
+// prefix included.
+func stringLength(data []byte) (int, error) {
+	sep := bytes.IndexByte(data, stringSeparator)
+	if sep <= 0 {
+		return 0, ErrStringInvalid
+	}
+
+	length := 0
+	for _, b := range data[:sep] {
+		if b < '0' || b > '9' {
+			return 0, ErrStringInvalid
+		}
+		// Checked on every digit so length can't overflow.
+		if length = length*10 + int(b-'0'); length > len(data) {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+	end := sep + 1 + length
+	if end > len(data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return end, nil
+}
+
+// canonicalInt returns the canonical form of an integer body.
+func canonicalInt(body []byte) ([]byte, error) {
+	neg := len(body) > 0 && body[0] == '-'
+	digits := body
+	if neg {
+		digits = body[1:]
+	}
+	if len(digits) == 0 {
+		return nil, ErrIntInvalid
+	}
+	for _, b := range digits {
+		if b < '0' || b > '9' {
+			return nil, ErrIntInvalid
+		}
+	}
+
+	digits = bytes.TrimLeft(digits, "0")
+	if len(digits) == 0 {
+		return []byte{'0'}, nil
+	}
+	if neg {
+		return append([]byte{'-'}, digits...), nil
+	}
+
+	return digits, nil
+}